@@ -1,22 +1,50 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "embed"
 	"os"
+	"path/filepath"
 
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/depotdownloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
 	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/handler"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/httpclient"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/jobs"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/retry"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
 	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/ugc"
 	"github.com/gin-gonic/gin"
 )
 
 var (
 	steamCmdPath, listenHost, listenPort, steamUser, steamPassword string
-	installSteamCmd, debugMode                                     bool
+	accountsFile, loginPolicy, adminToken, steamPasswordFile       string
+	appInstallRoot, backend, depotDownloaderPath                   string
+	depotDownloaderDir, depotDownloaderApps, appPlatforms          string
+	metadataCacheFile, userAgent, upstreamProxy                    string
+	steamAPIURL, steamCommunityURL, directDownloadDir, steamAPIKey string
+	installSteamCmd, debugMode, directDownloads                    bool
+	jobWorkers, jobQueueSize, retryAttempts, batchSize, instances  int
+	metadataCacheSize, httpMaxPerHost, httpRetries                 int
+	jobRetention, jobTimeout, itemTimeout, accountCooldown         time.Duration
+	steamGuardTimeout, itemCacheTTL, collectionCacheTTL            time.Duration
+	negativeCacheTTL, httpTimeout, httpMaxRetryWait                time.Duration
+	retryBaseDelay, retryMaxDelay                                  time.Duration
+	retryJitter, httpRateLimit                                     float64
 )
 
 func init() {
@@ -25,31 +53,248 @@ func init() {
 	flag.BoolVar(&debugMode, "debug", false, "Install steamcmd")
 	flag.StringVar(&listenHost, "listenhost", "0.0.0.0", "Hostname for the server to listen on")
 	flag.StringVar(&listenPort, "listenport", "8080", "Port for the server to listen on")
-	flag.StringVar(&steamUser, "steamuser", "", "Steam username")
-	flag.StringVar(&steamPassword, "steampassword", "", "Steam password")
+	flag.StringVar(&steamUser, "steamuser", os.Getenv("STEAM_USER"), "Steam username (or STEAM_USER)")
+	flag.StringVar(&steamPassword, "steampassword", "", "Steam password (deprecated: visible in the process list, use STEAM_PASSWORD or -steampasswordfile)")
+	flag.StringVar(&steamPasswordFile, "steampasswordfile", "", "File containing the Steam password")
+	flag.StringVar(&accountsFile, "accounts", "", "Path to a JSON file describing a pool of Steam accounts")
+	flag.StringVar(&loginPolicy, "loginpolicy", string(steamcmd.LoginAuto), "Default login policy: anonymous, account or auto (anonymous, then account when denied)")
+	flag.DurationVar(&accountCooldown, "accountcooldown", 15*time.Minute, "How long an account is rested after Steam rate limits it")
+	flag.DurationVar(&steamGuardTimeout, "steamguardtimeout", 5*time.Minute, "How long a login waits for a Steam Guard code submitted through the admin API")
+	flag.StringVar(&adminToken, "admintoken", "", "Bearer token for the /api/admin endpoints (disabled when empty)")
+	flag.IntVar(&jobWorkers, "jobworkers", 2, "Number of download jobs processed concurrently")
+	flag.IntVar(&jobQueueSize, "jobqueuesize", 100, "Maximum number of queued download jobs")
+	flag.DurationVar(&jobRetention, "jobretention", time.Hour, "How long finished jobs are kept for polling")
+	flag.DurationVar(&jobTimeout, "jobtimeout", 6*time.Hour, "Maximum run time of a download job (0 disables)")
+	flag.DurationVar(&itemTimeout, "itemtimeout", 30*time.Minute, "Maximum time steamcmd may spend on a single item (0 disables)")
+	flag.StringVar(&appInstallRoot, "appinstallroot", "", "Directory app downloads may install into via install_dir (disabled when empty)")
+	flag.StringVar(&backend, "backend", "steamcmd", "Default workshop download backend: steamcmd or depotdownloader")
+	flag.StringVar(&depotDownloaderPath, "depotdownloaderpath", "", "Path to the DepotDownloader executable (disabled when empty)")
+	flag.StringVar(&depotDownloaderDir, "depotdownloaderdir", "depotdownloader", "Directory DepotDownloader downloads workshop content into")
+	flag.StringVar(&depotDownloaderApps, "depotdownloaderapps", "", "Comma separated app IDs downloaded with DepotDownloader regardless of -backend")
+	flag.StringVar(&appPlatforms, "appplatforms", "", "Comma separated app_id=platform pairs (windows, linux or macos) forcing the platform content is downloaded for")
+	flag.BoolVar(&directDownloads, "directdownloads", true, "Download workshop items that have a file_url directly over HTTP instead of through the download backend")
+	flag.StringVar(&directDownloadDir, "directdownloaddir", "ugc", "Directory workshop items downloaded from their file_url are stored in")
+	flag.StringVar(&steamAPIURL, "steamapiurl", steam.DefaultAPIBaseURL, "Base URL of the Steam Web API used for workshop metadata")
+	flag.StringVar(&steamAPIKey, "steamapikey", os.Getenv("STEAM_API_KEY"), "Steam Web API key used for workshop search (or STEAM_API_KEY); without one the browse pages are scraped")
+	flag.IntVar(&metadataCacheSize, "metadatacachesize", 10000, "Maximum number of workshop items and collections kept in the metadata cache, 0 disables it")
+	flag.StringVar(&metadataCacheFile, "metadatacachefile", "", "File the metadata cache is persisted to across restarts (disabled when empty)")
+	flag.DurationVar(&itemCacheTTL, "itemcachettl", time.Hour, "How long workshop item metadata is cached")
+	flag.DurationVar(&collectionCacheTTL, "collectioncachettl", 15*time.Minute, "How long collection contents are cached")
+	flag.DurationVar(&negativeCacheTTL, "negativecachettl", 5*time.Minute, "How long items and collections that do not exist are remembered")
+	flag.StringVar(&steamCommunityURL, "steamcommunityurl", steam.DefaultCommunityBaseURL, "Base URL of the Steam Community site scraped when the Web API fails")
+	outbound := httpclient.DefaultConfig()
+	flag.DurationVar(&httpTimeout, "httptimeout", outbound.Timeout, "Timeout of outbound metadata and scraping requests (file downloads are not bounded)")
+	flag.StringVar(&userAgent, "useragent", outbound.UserAgent, "User-Agent sent with outbound requests")
+	flag.StringVar(&upstreamProxy, "upstreamproxy", "", "http, https or socks5 proxy URL for outbound requests (defaults to HTTP_PROXY/HTTPS_PROXY)")
+	flag.Float64Var(&httpRateLimit, "httpratelimit", outbound.RateLimit, "Maximum outbound requests per second per host, 0 disables the limit")
+	flag.IntVar(&httpMaxPerHost, "httpmaxperhost", outbound.MaxPerHost, "Maximum concurrent outbound requests per host, 0 disables the limit")
+	flag.IntVar(&httpRetries, "httpretries", outbound.MaxRetries, "Retries of outbound requests answered with 429 or a 5xx status")
+	flag.DurationVar(&httpMaxRetryWait, "httpmaxretrywait", outbound.MaxRetryWait, "Upper bound for the delay before an outbound retry, including Retry-After")
+	flag.IntVar(&instances, "instances", 1, "Number of isolated steamcmd instances used for parallel downloads")
+	flag.IntVar(&batchSize, "batchsize", 50, "Number of collection items downloaded per steamcmd session")
+
+	policy := retry.DefaultPolicy()
+	flag.IntVar(&retryAttempts, "retryattempts", policy.MaxAttempts, "Maximum steamcmd attempts per download")
+	flag.DurationVar(&retryBaseDelay, "retrybasedelay", policy.BaseDelay, "Delay before the first steamcmd retry, doubled on each further attempt")
+	flag.DurationVar(&retryMaxDelay, "retrymaxdelay", policy.MaxDelay, "Upper bound for the steamcmd retry delay")
+	flag.Float64Var(&retryJitter, "retryjitter", policy.Jitter, "Random fraction applied to each retry delay")
 
 	flag.Parse()
 }
 
+func parseAppIDs(list string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid app ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseAppPlatforms(list string) (map[int]steamcmd.Platform, error) {
+	platforms := make(map[int]steamcmd.Platform)
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		app, name, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected app_id=platform, got %q", field)
+		}
+		appID, err := strconv.Atoi(strings.TrimSpace(app))
+		if err != nil {
+			return nil, fmt.Errorf("invalid app ID %q", app)
+		}
+		platform, err := steamcmd.ParsePlatform(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		platforms[appID] = platform
+	}
+	return platforms, nil
+}
+
+func resolveSteamPassword() (string, error) {
+	switch {
+	case steamPasswordFile != "":
+		return steamcmd.ReadSecretFile(steamPasswordFile)
+	case steamPassword != "":
+		log.Println("⚠️ -steampassword exposes the password in the process list, prefer STEAM_PASSWORD or -steampasswordfile.")
+		return steamPassword, nil
+	default:
+		return os.Getenv("STEAM_PASSWORD"), nil
+	}
+}
+
 //go:embed favicon.ico
 var favicon []byte
 
 func main() {
-	s, err := steamcmd.New(steamCmdPath, steamUser, steamPassword)
+	httpClient, err := httpclient.New(httpclient.Config{
+		Timeout:      httpTimeout,
+		DialTimeout:  httpclient.DefaultConfig().DialTimeout,
+		UserAgent:    userAgent,
+		Proxy:        upstreamProxy,
+		RateLimit:    httpRateLimit,
+		MaxPerHost:   httpMaxPerHost,
+		MaxRetries:   httpRetries,
+		MaxRetryWait: httpMaxRetryWait,
+	})
+	if err != nil {
+		log.Fatalf("❌ Invalid outbound HTTP settings: %v", err)
+	}
+
+	s, err := steamcmd.New(steamCmdPath)
 	if err != nil {
 		log.Fatalf("❌ SteamCMD initialization error: %v", err)
 	}
+	s.HTTPClient = httpclient.WithoutTimeout(httpClient)
+
+	steamGuard := steamcmd.NewGuardBroker(steamGuardTimeout)
+	s.Guard = steamGuard
+
+	s.RetryPolicy = retry.Policy{
+		MaxAttempts: retryAttempts,
+		BaseDelay:   retryBaseDelay,
+		MaxDelay:    retryMaxDelay,
+		Jitter:      retryJitter,
+	}
 
 	if installSteamCmd {
 		if err := os.MkdirAll(steamCmdPath, 0755); err != nil {
 			log.Fatalf("❌ Failed to create steamcmd directory: %v", err)
 		}
 
-		if err := s.Install(); err != nil {
+		if err := s.Install(context.Background()); err != nil {
 			log.Printf("⚠️ SteamCMD installation warning: %v", err)
 		}
 	}
 
+	var accounts steamcmd.AccountsConfig
+	if accountsFile != "" {
+		if accounts, err = steamcmd.LoadAccounts(accountsFile); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+	if steamUser != "" {
+		password, err := resolveSteamPassword()
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		account := steamcmd.Account{Username: steamUser, Password: password}
+		if err := account.Validate(); err != nil {
+			log.Fatalf("❌ Invalid -steamuser account: %v", err)
+		}
+		accounts.Accounts = append(accounts.Accounts, account)
+	}
+
+	defaultPolicy, err := steamcmd.ParseLoginPolicy(loginPolicy)
+	if err != nil {
+		log.Fatalf("❌ Invalid -loginpolicy: %v", err)
+	}
+
+	logins, err := steamcmd.NewLoginPolicies(defaultPolicy, accounts.LoginPolicies, filepath.Join(steamCmdPath, "login_policies.json"))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	accountPool := steamcmd.NewAccountPool(accounts, accountCooldown)
+
+	pool, err := steamcmd.NewPool(s, instances, accountPool, logins)
+	if err != nil {
+		log.Fatalf("❌ SteamCMD pool initialization error: %v", err)
+	}
+
+	backends := map[string]downloader.Downloader{"steamcmd": pool}
+	backendApps := make(map[int]string)
+	if depotDownloaderPath != "" {
+		dd, err := depotdownloader.New(depotDownloaderPath, depotDownloaderDir, instances, accountPool)
+		if err != nil {
+			log.Fatalf("❌ DepotDownloader initialization error: %v", err)
+		}
+		dd.RetryPolicy = s.RetryPolicy
+		dd.Guard = steamGuard
+		backends["depotdownloader"] = dd
+
+		apps, err := parseAppIDs(depotDownloaderApps)
+		if err != nil {
+			log.Fatalf("❌ Invalid -depotdownloaderapps: %v", err)
+		}
+		for _, appID := range apps {
+			backendApps[appID] = "depotdownloader"
+		}
+	}
+
+	downloads, err := downloader.NewRouter(backends, backend, backendApps)
+	if err != nil {
+		log.Fatalf("❌ Invalid -backend: %v", err)
+	}
+
+	platforms, err := parseAppPlatforms(appPlatforms)
+	if err != nil {
+		log.Fatalf("❌ Invalid -appplatforms: %v", err)
+	}
+
+	steamClient := steam.NewClient()
+	steamClient.APIBaseURL = steamAPIURL
+	steamClient.CommunityBaseURL = steamCommunityURL
+	steamClient.APIKey = steamAPIKey
+	steamClient.HTTPClient = httpClient
+	if metadataCacheSize > 0 {
+		cache, err := steam.NewCache(steam.CacheConfig{
+			Size:          metadataCacheSize,
+			ItemTTL:       itemCacheTTL,
+			CollectionTTL: collectionCacheTTL,
+			NegativeTTL:   negativeCacheTTL,
+			Path:          metadataCacheFile,
+			FlushInterval: time.Minute,
+		})
+		if err != nil {
+			log.Fatalf("❌ Metadata cache initialization error: %v", err)
+		}
+		steamClient.Cache = cache
+	}
+
+	var workshopDownloads downloader.Downloader = downloads
+	if directDownloads {
+		direct, err := ugc.New(directDownloadDir, steamClient, downloads)
+		if err != nil {
+			log.Fatalf("❌ Direct download initialization error: %v", err)
+		}
+		direct.RetryPolicy = s.RetryPolicy
+		direct.HTTPClient = httpclient.WithoutTimeout(httpClient)
+		workshopDownloads = direct
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 
 	if debugMode {
@@ -58,15 +303,58 @@ func main() {
 
 	router := gin.Default()
 
-	h := handler.New(s)
+	h := handler.New(pool, handler.Config{
+		Jobs: jobs.Config{
+			Workers:   jobWorkers,
+			QueueSize: jobQueueSize,
+			Retention: jobRetention,
+			Timeout:   jobTimeout,
+		},
+		Downloader:     workshopDownloads,
+		Steam:          steamClient,
+		BatchSize:      batchSize,
+		ItemTimeout:    itemTimeout,
+		SteamGuard:     steamGuard,
+		AdminToken:     adminToken,
+		AppInstallRoot: appInstallRoot,
+		AppPlatforms:   platforms,
+		HTTPClient:     httpClient,
+	})
 	defer h.Cleanup()
+	defer func() {
+		if err := steamClient.Cache.Close(); err != nil {
+			log.Printf("⚠️ Failed to persist metadata cache: %v", err)
+		}
+	}()
 
 	router.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/workshop/")
 	})
 
 	router.GET("/api/workshop/:app_id/:workshop_id", h.DownloadWorkshopHandler)
+	router.GET("/api/workshop/:app_id/:workshop_id/details", h.WorkshopDetailsHandler)
 	router.GET("/api/collection/:app_id/:collection_id", h.DownloadCollectionHandler)
+	router.GET("/api/app/:app_id", h.DownloadAppHandler)
+	router.GET("/api/search", h.SearchHandler)
+	router.GET("/api/health", h.HealthHandler)
+
+	router.GET("/api/inventory", h.InventoryHandler)
+	router.GET("/api/inventory/:app_id", h.AppInventoryHandler)
+	router.DELETE("/api/inventory/:app_id", h.RequireAdmin, h.DeleteAppInventoryHandler)
+	router.DELETE("/api/inventory/:app_id/:workshop_id", h.RequireAdmin, h.DeleteInventoryItemHandler)
+	router.GET("/api/depot/:app_id/:depot_id/:manifest_id", h.DownloadDepotHandler)
+
+	router.POST("/api/jobs", h.CreateJobHandler)
+	router.GET("/api/jobs/:id", h.GetJobHandler)
+	router.DELETE("/api/jobs/:id", h.CancelJobHandler)
+	router.GET("/api/jobs/:id/result", h.GetJobResultHandler)
+	router.GET("/api/jobs/:id/events", h.JobEventsHandler)
+
+	admin := router.Group("/api/admin", h.RequireAdmin)
+	admin.GET("/steamguard", h.PendingSteamGuardHandler)
+	admin.POST("/steamguard", h.SubmitSteamGuardHandler)
+	admin.DELETE("/cache", h.ClearCacheHandler)
+	admin.DELETE("/cache/:id", h.InvalidateCacheHandler)
 
 	router.Any("/workshop/*path", h.SteamProxyHandler)
 	router.Any("/app/*path", h.SteamProxyHandler)
@@ -89,7 +377,22 @@ func main() {
 
 	log.Printf("🚀 Server starting on http://%s", listenAddr)
 
-	if err := router.Run(listenAddr); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	// Serve until interrupted, so the deferred cleanup runs on shutdown.
+	server := &http.Server{Addr: listenAddr, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ Failed to start server: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+
+	log.Printf("ℹ️ Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Server shutdown: %v", err)
 	}
 }
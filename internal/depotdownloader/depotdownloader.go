@@ -0,0 +1,277 @@
+package depotdownloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/retry"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
+)
+
+// DepotDownloader downloads workshop items with the DepotDownloader CLI
+// (https://github.com/SteamRE/DepotDownloader) instead of steamcmd. It
+// reports results with the steamcmd package's errors so both backends are
+// interchangeable.
+type DepotDownloader struct {
+	ExePath     string
+	ContentRoot string
+	RetryPolicy retry.Policy
+	// Guard supplies Steam Guard codes for accounts without a shared secret.
+	Guard *steamcmd.GuardBroker
+
+	accounts *steamcmd.AccountPool
+	slots    chan struct{}
+}
+
+// New resolves exePath, which may also be a command on the PATH. At most
+// concurrency DepotDownloader processes run at once. Apps served by an
+// account in accounts are downloaded with that account, others anonymously.
+func New(exePath, contentRoot string, concurrency int, accounts *steamcmd.AccountPool) (*DepotDownloader, error) {
+	resolved, err := exec.LookPath(exePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find DepotDownloader: %w", err)
+	}
+
+	root, err := filepath.Abs(contentRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve DepotDownloader content directory: %w", err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create DepotDownloader content directory: %w", err)
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if accounts == nil {
+		accounts = steamcmd.NewAccountPool(steamcmd.AccountsConfig{}, 0)
+	}
+
+	return &DepotDownloader{
+		ExePath:     resolved,
+		ContentRoot: root,
+		RetryPolicy: retry.DefaultPolicy(),
+		accounts:    accounts,
+		slots:       make(chan struct{}, concurrency),
+	}, nil
+}
+
+func (d *DepotDownloader) Size() int {
+	return cap(d.slots)
+}
+
+func (d *DepotDownloader) Health(ctx context.Context) error {
+	if _, err := os.Stat(d.ExePath); err != nil {
+		return fmt.Errorf("DepotDownloader binary is missing: %w", err)
+	}
+	if _, err := os.Stat(d.ContentRoot); err != nil {
+		return fmt.Errorf("DepotDownloader content directory is missing: %w", err)
+	}
+	return nil
+}
+
+// GetWorkshopContentPath keeps builds for a forced platform apart from the
+// host's, like steamcmd.SteamCMD.AppContentPath.
+func (d *DepotDownloader) GetWorkshopContentPath(appID, workshopID int, platform steamcmd.Platform) string {
+	dir := fmt.Sprint(workshopID)
+	if platform != "" {
+		dir += "_" + string(platform)
+	}
+	return filepath.Join(d.ContentRoot, fmt.Sprint(appID), dir)
+}
+
+func (d *DepotDownloader) DownloadWorkshopItem(ctx context.Context, appID, workshopID int, opts steamcmd.DownloadOptions) error {
+	return d.DownloadWorkshopItems(ctx, appID, []int{workshopID}, opts)[0].Err
+}
+
+// DownloadWorkshopItems downloads the items one after another, since
+// DepotDownloader handles a single published file per run.
+func (d *DepotDownloader) DownloadWorkshopItems(ctx context.Context, appID int, workshopIDs []int, opts steamcmd.DownloadOptions) []steamcmd.ItemResult {
+	final := make(map[int]error, len(workshopIDs))
+
+	for _, id := range workshopIDs {
+		if _, done := final[id]; done {
+			continue
+		}
+
+		err := d.downloadItem(ctx, appID, id, opts)
+		final[id] = err
+		if opts.OnResult != nil {
+			opts.OnResult(steamcmd.ItemResult{WorkshopID: id, Err: err})
+		}
+	}
+
+	results := make([]steamcmd.ItemResult, len(workshopIDs))
+	for i, id := range workshopIDs {
+		results[i] = steamcmd.ItemResult{WorkshopID: id, Err: final[id]}
+	}
+	return results
+}
+
+func (d *DepotDownloader) downloadItem(ctx context.Context, appID, workshopID int, opts steamcmd.DownloadOptions) error {
+	select {
+	case d.slots <- struct{}{}:
+		defer func() { <-d.slots }()
+	case <-ctx.Done():
+		return fmt.Errorf("DepotDownloader cancelled: %w", context.Cause(ctx))
+	}
+
+	account, release, err := d.account(ctx, appID)
+	if err != nil {
+		return err
+	}
+
+	if opts.OnStart != nil {
+		opts.OnStart(workshopID)
+	}
+
+	_, err = d.RetryPolicy.Do(ctx, steamcmd.IsTransient, func(attempt int, err error, delay time.Duration) {
+		log.Printf("⚠️ DepotDownloader attempt %d/%d failed for item %d: %v (retrying in %s)", attempt, d.RetryPolicy.MaxAttempts, workshopID, err, delay.Round(time.Millisecond))
+	}, func() error {
+		return d.run(ctx, appID, workshopID, account, opts)
+	})
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("DepotDownloader cancelled: %w", context.Cause(ctx))
+	}
+
+	release(err)
+	return err
+}
+
+func (d *DepotDownloader) account(ctx context.Context, appID int) (*steamcmd.Account, func(error), error) {
+	if !d.accounts.Serves(appID) {
+		return nil, func(error) {}, nil
+	}
+
+	account, release, err := d.accounts.Acquire(ctx, appID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no steam account available: %w", err)
+	}
+	return account, release, nil
+}
+
+func (d *DepotDownloader) run(ctx context.Context, appID, workshopID int, account *steamcmd.Account, opts steamcmd.DownloadOptions) error {
+	dir := d.GetWorkshopContentPath(appID, workshopID, opts.Platform)
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if opts.ItemTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		runCtx, cancelTimeout = context.WithTimeoutCause(runCtx, opts.ItemTimeout, steamcmd.ErrTimeout)
+		defer cancelTimeout()
+	}
+
+	args := []string{"-app", fmt.Sprint(appID), "-pubfile", fmt.Sprint(workshopID), "-dir", dir}
+	if opts.Validate {
+		args = append(args, "-validate")
+	}
+	if opts.Platform != "" {
+		args = append(args, "-os", string(opts.Platform))
+	}
+	if account != nil {
+		// The password is answered on stdin rather than passed as -password.
+		args = append(args, "-username", account.Username, "-remember-password")
+	}
+
+	cmd := exec.CommandContext(runCtx, d.ExePath, args...)
+	cmd.Dir = d.ContentRoot
+	cmd.WaitDelay = 5 * time.Second
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open DepotDownloader stdin: %w", err)
+	}
+
+	parser := &outputParser{}
+	prompts := &promptResponder{ctx: runCtx, account: account, broker: d.Guard, stdin: stdin, abort: cancel}
+	cmd.Stdout = &util.LineWriter{
+		OnLine: func(line string) {
+			prompts.onLine()
+			parser.parseLine(line)
+			if opts.OnProgress != nil {
+				if percent, ok := parseProgress(line); ok {
+					opts.OnProgress(workshopID, percent)
+				}
+			}
+		},
+		OnPartial: prompts.onPartial,
+	}
+	cmd.Stderr = &util.LineWriter{OnLine: func(line string) {
+		parser.parseLine(line)
+		fmt.Fprintln(os.Stderr, steamcmd.Redact(line, account))
+	}}
+
+	runErr := cmd.Run()
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("DepotDownloader cancelled: %w", context.Cause(ctx))
+	}
+	if cause := context.Cause(runCtx); cause != nil {
+		if errors.Is(cause, steamcmd.ErrTimeout) {
+			return &steamcmd.DownloadError{WorkshopID: workshopID, Reason: fmt.Sprintf("no result within %s", opts.ItemTimeout), Err: steamcmd.ErrTimeout}
+		}
+		return cause
+	}
+
+	return parser.result(workshopID, runErr)
+}
+
+// promptResponder answers DepotDownloader's password and Steam Guard prompts,
+// which are written without a trailing newline.
+type promptResponder struct {
+	ctx     context.Context
+	account *steamcmd.Account
+	broker  *steamcmd.GuardBroker
+	stdin   io.Writer
+	abort   context.CancelCauseFunc
+
+	prompted bool
+}
+
+func (p *promptResponder) onLine() {
+	p.prompted = false
+}
+
+func (p *promptResponder) onPartial(text string) {
+	if p.prompted {
+		return
+	}
+
+	switch {
+	case passwordPromptRegex.MatchString(text):
+		p.prompted = true
+		if p.account == nil {
+			p.fail(errors.New("password requested for an anonymous login"))
+			return
+		}
+		p.write(p.account.Password)
+	case guardPromptRegex.MatchString(text):
+		p.prompted = true
+		go func() {
+			code, err := steamcmd.GuardCode(p.ctx, p.account, p.broker)
+			if err != nil {
+				p.fail(err)
+				return
+			}
+			p.write(code)
+		}()
+	}
+}
+
+func (p *promptResponder) write(answer string) {
+	if _, err := io.WriteString(p.stdin, answer+"\n"); err != nil {
+		p.fail(err)
+	}
+}
+
+func (p *promptResponder) fail(err error) {
+	p.abort(&steamcmd.DownloadError{Reason: "DepotDownloader login: " + err.Error(), Err: steamcmd.ErrLoginFailed})
+}
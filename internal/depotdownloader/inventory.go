@@ -0,0 +1,117 @@
+package depotdownloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
+)
+
+// WorkshopApps lists the apps with a directory below ContentRoot.
+func (d *DepotDownloader) WorkshopApps() ([]int, error) {
+	entries, err := os.ReadDir(d.ContentRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DepotDownloader content directory: %w", err)
+	}
+
+	var apps []int
+	for _, entry := range entries {
+		if appID, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			apps = append(apps, appID)
+		}
+	}
+	sort.Ints(apps)
+	return apps, nil
+}
+
+// InstalledWorkshopItems lists the item directories of an app. DepotDownloader
+// keeps no workshop manifest, so the directory's modification time stands in
+// for the item's update time and the manifest ID is unknown.
+func (d *DepotDownloader) InstalledWorkshopItems(appID int) ([]steamcmd.InstalledItem, error) {
+	dir := filepath.Join(d.ContentRoot, fmt.Sprint(appID))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DepotDownloader content directory: %w", err)
+	}
+
+	var items []steamcmd.InstalledItem
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		id, platform, ok := parseItemDir(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		size, _ := util.DirSize(path)
+		items = append(items, steamcmd.InstalledItem{
+			WorkshopID:  id,
+			Size:        size,
+			TimeUpdated: info.ModTime().UTC(),
+			Platform:    platform,
+			Path:        path,
+		})
+	}
+	return items, nil
+}
+
+func (d *DepotDownloader) InstalledWorkshopItem(appID, workshopID int, platform steamcmd.Platform) (steamcmd.InstalledItem, bool, error) {
+	path := d.GetWorkshopContentPath(appID, workshopID, platform)
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return steamcmd.InstalledItem{}, false, nil
+	}
+
+	size, _ := util.DirSize(path)
+	return steamcmd.InstalledItem{
+		WorkshopID:  workshopID,
+		Size:        size,
+		TimeUpdated: info.ModTime().UTC(),
+		Platform:    platform,
+		Path:        path,
+	}, true, nil
+}
+
+func (d *DepotDownloader) RemoveWorkshopItem(appID, workshopID int) (bool, error) {
+	removed := false
+	for _, platform := range steamcmd.Platforms {
+		path := d.GetWorkshopContentPath(appID, workshopID, platform)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("failed to remove workshop item %d: %w", workshopID, err)
+		}
+		removed = true
+	}
+	return removed, nil
+}
+
+// parseItemDir splits the directory names GetWorkshopContentPath creates.
+func parseItemDir(name string) (int, steamcmd.Platform, bool) {
+	idPart, platformPart, _ := strings.Cut(name, "_")
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, "", false
+	}
+	platform, err := steamcmd.ParsePlatform(platformPart)
+	if err != nil {
+		return 0, "", false
+	}
+	return id, platform, true
+}
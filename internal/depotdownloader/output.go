@@ -0,0 +1,97 @@
+package depotdownloader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+var (
+	progressRegex       = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)%`)
+	doneRegex           = regexp.MustCompile(`^Total downloaded: `)
+	passwordPromptRegex = regexp.MustCompile(`(?i)enter account password.*:\s*$`)
+	guardPromptRegex    = regexp.MustCompile(`(?i)(?:2[- ]factor|authenticator|auth(?:entication)? code).*:\s*$`)
+)
+
+// failures maps DepotDownloader messages to the steamcmd errors the API
+// already understands. They are checked in order.
+var failures = []struct {
+	marker string
+	err    error
+}{
+	{"is not available from this account", steamcmd.ErrNoSubscription},
+	{"unable to locate manifest", steamcmd.ErrManifestNotFound},
+	{"accessdenied", steamcmd.ErrAccessDenied},
+	{"access denied", steamcmd.ErrAccessDenied},
+	{"ratelimitexceeded", steamcmd.ErrRateLimited},
+	{"invalidpassword", steamcmd.ErrLoginFailed},
+	{"failed to authenticate", steamcmd.ErrLoginFailed},
+	{"unable to login", steamcmd.ErrLoginFailed},
+	{"filenotfound", steamcmd.ErrItemNotFound},
+	{"not found", steamcmd.ErrItemNotFound},
+	{"timeout", steamcmd.ErrTimeout},
+	{"timed out", steamcmd.ErrTimeout},
+}
+
+type outputParser struct {
+	mu     sync.Mutex
+	done   bool
+	reason string
+	err    error
+}
+
+func (p *outputParser) parseLine(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if doneRegex.MatchString(line) {
+		p.done = true
+		return
+	}
+
+	if p.err != nil {
+		return
+	}
+	lower := strings.ToLower(line)
+	for _, f := range failures {
+		if strings.Contains(lower, f.marker) {
+			p.reason = line
+			p.err = f.err
+			return
+		}
+	}
+}
+
+// result reports the outcome of a run. Recognised failures win; otherwise the
+// run only succeeded if DepotDownloader printed its download summary.
+func (p *outputParser) result(workshopID int, runErr error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.err != nil:
+		return &steamcmd.DownloadError{WorkshopID: workshopID, Reason: p.reason, Err: p.err}
+	case runErr != nil:
+		return fmt.Errorf("%w: item %d: %w", steamcmd.ErrDownloadFailed, workshopID, runErr)
+	case !p.done:
+		return &steamcmd.DownloadError{WorkshopID: workshopID, Reason: "no download summary reported", Err: steamcmd.ErrDownloadFailed}
+	default:
+		return nil
+	}
+}
+
+func parseProgress(line string) (float64, bool) {
+	matches := progressRegex.FindStringSubmatch(line)
+	if len(matches) != 2 {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return percent, true
+}
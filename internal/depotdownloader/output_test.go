@@ -0,0 +1,93 @@
+package depotdownloader
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+func TestOutputParser(t *testing.T) {
+	runErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name   string
+		lines  []string
+		runErr error
+		want   error // nil for success
+	}{
+		{"done", []string{"Downloading depot 4000", "100.00% mod.txt", "Total downloaded: 2 bytes (2 bytes uncompressed) from 1 depots"}, nil, nil},
+		{"no summary", []string{"100.00% mod.txt"}, nil, steamcmd.ErrDownloadFailed},
+		{"exit status", []string{"Total downloaded: 2 bytes"}, runErr, runErr},
+		{"manifest", []string{"Encountered error downloading manifest: Unable to locate manifest"}, runErr, steamcmd.ErrManifestNotFound},
+		{"not owned", []string{"App 4000 is not available from this account."}, runErr, steamcmd.ErrNoSubscription},
+		{"password", []string{"Failed to authenticate with Steam: InvalidPassword"}, runErr, steamcmd.ErrLoginFailed},
+		{"rate limit", []string{"Unable to login: RateLimitExceeded"}, runErr, steamcmd.ErrRateLimited},
+		{"first failure wins", []string{"Access Denied", "Connection timed out"}, runErr, steamcmd.ErrAccessDenied},
+		{"failure beats summary", []string{"Item not found", "Total downloaded: 0 bytes"}, nil, steamcmd.ErrItemNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &outputParser{}
+			for _, line := range tt.lines {
+				p.parseLine(line)
+			}
+			if err := p.result(7, tt.runErr); !errors.Is(err, tt.want) {
+				t.Errorf("result = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputParserStartFailure(t *testing.T) {
+	runErr := exec.Command("/nonexistent/DepotDownloader").Run()
+
+	err := (&outputParser{}).result(7, runErr)
+	if !errors.Is(err, steamcmd.ErrDownloadFailed) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("result = %v, want it to wrap %v and the start error", err, steamcmd.ErrDownloadFailed)
+	}
+	if steamcmd.IsTransient(err) {
+		t.Errorf("IsTransient(%v) = true, want a missing binary to be permanent", err)
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line string
+		want float64
+		ok   bool
+	}{
+		{" 45.50% /content/mod.txt", 45.5, true},
+		{"100.00% /content/mod.txt", 100, true},
+		{"Downloading depot 4000 - 50% done", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseProgress(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseProgress(%q) = %v, %t, want %v, %t", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseItemDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       int
+		platform steamcmd.Platform
+		ok       bool
+	}{
+		{"123", 123, "", true},
+		{"123_windows", 123, steamcmd.PlatformWindows, true},
+		{"123_macos", 123, steamcmd.PlatformMacOS, true},
+		{"123_amiga", 0, "", false},
+		{".DepotDownloader", 0, "", false},
+	}
+	for _, tt := range tests {
+		id, platform, ok := parseItemDir(tt.name)
+		if id != tt.id || platform != tt.platform || ok != tt.ok {
+			t.Errorf("parseItemDir(%q) = %d, %q, %t, want %d, %q, %t", tt.name, id, platform, ok, tt.id, tt.platform, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+// Downloader is a workshop download engine. *steamcmd.Pool and
+// *depotdownloader.DepotDownloader both implement it.
+type Downloader interface {
+	DownloadWorkshopItem(ctx context.Context, appID, workshopID int, opts steamcmd.DownloadOptions) error
+	// DownloadWorkshopItems downloads a batch of items, reporting each
+	// outcome through opts.OnResult as well as in the returned slice.
+	DownloadWorkshopItems(ctx context.Context, appID int, workshopIDs []int, opts steamcmd.DownloadOptions) []steamcmd.ItemResult
+	// GetWorkshopContentPath locates an item downloaded for platform, which
+	// is empty for the host platform.
+	GetWorkshopContentPath(appID, workshopID int, platform steamcmd.Platform) string
+	// Size is the number of batches that can usefully run in parallel.
+	Size() int
+	Health(ctx context.Context) error
+}
+
+// ErrManifestUnsupported is returned for pinned workshop manifests when the
+// app's backend cannot download historical builds.
+var ErrManifestUnsupported = errors.New("the download backend cannot pin workshop manifests")
+
+// ManifestDownloader is implemented by backends that can download a workshop
+// item as of a historical manifest. It returns where the content was stored.
+type ManifestDownloader interface {
+	DownloadWorkshopManifest(ctx context.Context, appID, workshopID int, opts steamcmd.DepotOptions) (string, error)
+}
+
+// Router sends every app to the backend configured for it, or to the
+// default one.
+type Router struct {
+	backends map[string]Downloader
+	fallback string
+	apps     map[int]string
+}
+
+// NewRouter creates a Router over the named backends. apps maps app IDs to
+// backend names; every other app uses fallback.
+func NewRouter(backends map[string]Downloader, fallback string, apps map[int]string) (*Router, error) {
+	if _, ok := backends[fallback]; !ok {
+		return nil, fmt.Errorf("unknown download backend %q", fallback)
+	}
+	for appID, name := range apps {
+		if _, ok := backends[name]; !ok {
+			return nil, fmt.Errorf("unknown download backend %q for app %d", name, appID)
+		}
+	}
+
+	return &Router{backends: backends, fallback: fallback, apps: apps}, nil
+}
+
+// Backend returns the name of the backend used for appID.
+func (r *Router) Backend(appID int) string {
+	if name, ok := r.apps[appID]; ok {
+		return name
+	}
+	return r.fallback
+}
+
+func (r *Router) For(appID int) Downloader {
+	return r.backends[r.Backend(appID)]
+}
+
+func (r *Router) DownloadWorkshopItem(ctx context.Context, appID, workshopID int, opts steamcmd.DownloadOptions) error {
+	return r.For(appID).DownloadWorkshopItem(ctx, appID, workshopID, opts)
+}
+
+func (r *Router) DownloadWorkshopItems(ctx context.Context, appID int, workshopIDs []int, opts steamcmd.DownloadOptions) []steamcmd.ItemResult {
+	return r.For(appID).DownloadWorkshopItems(ctx, appID, workshopIDs, opts)
+}
+
+func (r *Router) GetWorkshopContentPath(appID, workshopID int, platform steamcmd.Platform) string {
+	return r.For(appID).GetWorkshopContentPath(appID, workshopID, platform)
+}
+
+func (r *Router) DownloadWorkshopManifest(ctx context.Context, appID, workshopID int, opts steamcmd.DepotOptions) (string, error) {
+	md, ok := r.For(appID).(ManifestDownloader)
+	if !ok {
+		return "", fmt.Errorf("%w: app %d uses %s", ErrManifestUnsupported, appID, r.Backend(appID))
+	}
+	return md.DownloadWorkshopManifest(ctx, appID, workshopID, opts)
+}
+
+func (r *Router) Size() int {
+	size := 1
+	for _, b := range r.backends {
+		size = max(size, b.Size())
+	}
+	return size
+}
+
+// Health reports every unhealthy backend.
+func (r *Router) Health(ctx context.Context) error {
+	var errs []error
+	for name, b := range r.backends {
+		if err := b.Health(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Statuses reports the health of each backend by name, nil meaning healthy.
+func (r *Router) Statuses(ctx context.Context) map[string]error {
+	statuses := make(map[string]error, len(r.backends))
+	for name, b := range r.backends {
+		statuses[name] = b.Health(ctx)
+	}
+	return statuses
+}
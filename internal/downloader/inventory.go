@@ -0,0 +1,136 @@
+package downloader
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+// Inventory is implemented by backends that can list and remove the
+// workshop content they downloaded.
+type Inventory interface {
+	WorkshopApps() ([]int, error)
+	InstalledWorkshopItems(appID int) ([]steamcmd.InstalledItem, error)
+	// InstalledWorkshopItem looks up one item and platform without listing
+	// the whole app.
+	InstalledWorkshopItem(appID, workshopID int, platform steamcmd.Platform) (steamcmd.InstalledItem, bool, error)
+	// RemoveWorkshopItem deletes every platform's copy of an item and
+	// reports whether there was any.
+	RemoveWorkshopItem(appID, workshopID int) (bool, error)
+}
+
+// MergeInstalled keeps the most recently updated copy of every item and
+// platform found in lists.
+func MergeInstalled(lists ...[]steamcmd.InstalledItem) []steamcmd.InstalledItem {
+	type key struct {
+		id       int
+		platform steamcmd.Platform
+	}
+	latest := make(map[key]steamcmd.InstalledItem)
+	for _, list := range lists {
+		for _, item := range list {
+			k := key{item.WorkshopID, item.Platform}
+			if prev, ok := latest[k]; !ok || item.TimeUpdated.After(prev.TimeUpdated) {
+				latest[k] = item
+			}
+		}
+	}
+
+	items := make([]steamcmd.InstalledItem, 0, len(latest))
+	for _, item := range latest {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].WorkshopID != items[j].WorkshopID {
+			return items[i].WorkshopID < items[j].WorkshopID
+		}
+		return items[i].Platform < items[j].Platform
+	})
+	return items
+}
+
+// inventories returns the distinct backends of r that keep an inventory.
+func (r *Router) inventories() []Inventory {
+	seen := make(map[Downloader]bool)
+	var inventories []Inventory
+	for _, name := range r.names() {
+		b := r.backends[name]
+		if inv, ok := b.(Inventory); ok && !seen[b] {
+			seen[b] = true
+			inventories = append(inventories, inv)
+		}
+	}
+	return inventories
+}
+
+func (r *Router) names() []string {
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Router) WorkshopApps() ([]int, error) {
+	seen := make(map[int]bool)
+	for _, inv := range r.inventories() {
+		apps, err := inv.WorkshopApps()
+		if err != nil {
+			return nil, err
+		}
+		for _, appID := range apps {
+			seen[appID] = true
+		}
+	}
+
+	apps := make([]int, 0, len(seen))
+	for appID := range seen {
+		apps = append(apps, appID)
+	}
+	sort.Ints(apps)
+	return apps, nil
+}
+
+// InstalledWorkshopItems merges the items of every backend, since an app may
+// have been routed to another backend when an item was downloaded.
+func (r *Router) InstalledWorkshopItems(appID int) ([]steamcmd.InstalledItem, error) {
+	var lists [][]steamcmd.InstalledItem
+	for _, inv := range r.inventories() {
+		items, err := inv.InstalledWorkshopItems(appID)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, items)
+	}
+	return MergeInstalled(lists...), nil
+}
+
+func (r *Router) InstalledWorkshopItem(appID, workshopID int, platform steamcmd.Platform) (steamcmd.InstalledItem, bool, error) {
+	var (
+		latest steamcmd.InstalledItem
+		found  bool
+	)
+	for _, inv := range r.inventories() {
+		item, ok, err := inv.InstalledWorkshopItem(appID, workshopID, platform)
+		if err != nil {
+			return steamcmd.InstalledItem{}, false, err
+		}
+		if ok && (!found || item.TimeUpdated.After(latest.TimeUpdated)) {
+			latest, found = item, true
+		}
+	}
+	return latest, found, nil
+}
+
+func (r *Router) RemoveWorkshopItem(appID, workshopID int) (bool, error) {
+	removed := false
+	var errs []error
+	for _, inv := range r.inventories() {
+		ok, err := inv.RemoveWorkshopItem(appID, workshopID)
+		removed = removed || ok
+		errs = append(errs, err)
+	}
+	return removed, errors.Join(errs...)
+}
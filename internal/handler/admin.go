@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/gin-gonic/gin"
+)
+
+type steamGuardCode struct {
+	Username string `json:"username" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RequireAdmin guards administrative endpoints with the configured bearer
+// token. Without a token they are disabled entirely.
+func (h *SteamDownloaderAPI) RequireAdmin(c *gin.Context) {
+	if h.adminToken == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled"})
+		return
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+		return
+	}
+
+	c.Next()
+}
+
+func (h *SteamDownloaderAPI) PendingSteamGuardHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.steamGuard.Pending())
+}
+
+func (h *SteamDownloaderAPI) SubmitSteamGuardHandler(c *gin.Context) {
+	var req steamGuardCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.steamGuard.Submit(req.Username, strings.TrimSpace(req.Code)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, steamcmd.ErrNoGuardPrompt) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SteamDownloaderAPI) ClearCacheHandler(c *gin.Context) {
+	if h.steam.Cache == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "metadata cache is disabled"})
+		return
+	}
+
+	h.steam.Cache.Clear()
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SteamDownloaderAPI) InvalidateCacheHandler(c *gin.Context) {
+	if h.steam.Cache == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "metadata cache is disabled"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+
+	h.steam.Cache.Invalidate(id)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *SteamDownloaderAPI) WorkshopDetailsHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Param("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
+
+	workshopID, err := strconv.Atoi(c.Param("workshop_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workshop ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if refresh, _ := strconv.ParseBool(c.Query("refresh")); refresh {
+		ctx = steam.WithRefresh(ctx)
+	}
+
+	item, err := h.steam.GetWorkshopItem(ctx, workshopID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, steam.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The scraper fallback cannot tell the app, so only reject a known mismatch.
+	if item.AppID != 0 && item.AppID != appID {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("workshop item %d belongs to app %d", workshopID, item.AppID)})
+		return
+	}
+
+	h.titles.Store(item.ID, item.Title)
+	c.JSON(http.StatusOK, item)
+}
@@ -1,15 +1,22 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/jobs"
 	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
 	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
 	"github.com/gin-gonic/gin"
 	"github.com/schollz/progressbar/v3"
@@ -18,121 +25,667 @@ import (
 func (h *SteamDownloaderAPI) DownloadWorkshopHandler(c *gin.Context) {
 	appID, err := strconv.Atoi(c.Param("app_id"))
 	if err != nil {
-		c.String(http.StatusBadRequest, "Invalid App ID.")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
 		return
 	}
 
 	workshopID, err := strconv.Atoi(c.Param("workshop_id"))
 	if err != nil {
-		c.String(http.StatusBadRequest, "Invalid Workshop ID.")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workshop ID"})
 		return
 	}
 
-	workshopName, err := steam.GetWorkshopName(workshopID)
+	manifestID, err := parseManifestID(c.Query("manifest"))
 	if err != nil {
-		c.String(http.StatusNotFound, "Could not find workshop item: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	zipFileName := fmt.Sprintf("%d_%s.zip", workshopID, util.SanitizeFileName(workshopName))
-	zipFilePath := filepath.Join(h.saveDirectory, zipFileName)
-
-	if _, err := os.Stat(zipFilePath); !os.IsNotExist(err) {
-		c.FileAttachment(zipFilePath, zipFileName)
+	skipDependencies, err := parseSkipDependencies(c.Query("dependencies"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("⬇️ Starting download for AppID: %d, WorkshopID: %d", appID, workshopID)
+	h.runJobSync(c, jobs.Request{
+		Kind:             jobs.KindWorkshop,
+		AppID:            appID,
+		ID:               workshopID,
+		ManifestID:       manifestID,
+		SkipDependencies: skipDependencies,
+	})
+}
+
+func (h *SteamDownloaderAPI) DownloadDepotHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Param("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
 
-	if err := h.steamcmd.DownloadWorkshopItem(appID, workshopID, true); err != nil {
-		c.String(http.StatusInternalServerError, "Failed to download item: %v", err)
+	depotID, err := strconv.Atoi(c.Param("depot_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depot ID"})
 		return
 	}
-	log.Printf("✅ Downloaded AppID: %d, WorkshopID: %d. Now zipping...", appID, workshopID)
 
-	sourcePath := h.steamcmd.GetWorkshopContentPath(appID, workshopID)
+	manifest := c.Param("manifest_id")
+	if manifest == "latest" {
+		manifest = ""
+	}
 
-	if err := util.ZipDirectory(sourcePath, zipFilePath); err != nil {
-		c.String(http.StatusInternalServerError, "Failed to create zip archive: %v", err)
+	manifestID, err := parseManifestID(manifest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	log.Printf("📦 Zipped successfully: %s", zipFileName)
 
-	c.FileAttachment(zipFilePath, zipFileName)
+	h.runJobSync(c, jobs.Request{Kind: jobs.KindDepot, AppID: appID, ID: depotID, ManifestID: manifestID})
+}
+
+func parseManifestID(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	manifestID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid manifest ID")
+	}
+	return manifestID, nil
+}
+
+// parseSkipDependencies reads the dependencies query parameter, which
+// defaults to true.
+func parseSkipDependencies(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("invalid dependencies value, expected true or false")
+	}
+	return !include, nil
 }
 
 func (h *SteamDownloaderAPI) DownloadCollectionHandler(c *gin.Context) {
-	appID, _ := strconv.Atoi(c.Param("app_id"))
-	collectionID, _ := strconv.Atoi(c.Param("collection_id"))
+	appID, err := strconv.Atoi(c.Param("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
 
-	log.Printf("⬇️ Starting download for CollectionID: %d", collectionID)
+	collectionID, err := strconv.Atoi(c.Param("collection_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID"})
+		return
+	}
+
+	h.runJobSync(c, jobs.Request{Kind: jobs.KindCollection, AppID: appID, ID: collectionID})
+}
 
-	collectionTitle, items, err := steam.GetCollectionItems(collectionID)
+func (h *SteamDownloaderAPI) DownloadAppHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Param("app_id"))
 	if err != nil {
-		c.String(http.StatusNotFound, "Could not get collection items: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
 		return
 	}
 
-	if len(items) == 0 {
-		c.String(http.StatusNotFound, "Collection is empty or could not be found.")
+	req := jobs.Request{
+		Kind:         jobs.KindApp,
+		AppID:        appID,
+		Beta:         c.Query("beta"),
+		BetaPassword: c.GetHeader("X-Beta-Password"),
+		InstallDir:   c.Query("install_dir"),
+	}
+
+	h.runJobSync(c, req)
+}
+
+func (h *SteamDownloaderAPI) runJobSync(c *gin.Context, req jobs.Request) {
+	req.Platform = c.Query("platform")
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.prepareRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	zipFileName := fmt.Sprintf("%d_%s_collection.zip", collectionID, util.SanitizeFileName(collectionTitle))
+	job, err := h.jobs.SubmitAndWait(c.Request.Context(), req)
+	if err != nil {
+		if job == nil {
+			respondError(c, err)
+		}
+		return
+	}
+
+	result, err := job.Result()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if result.FilePath == "" {
+		c.JSON(http.StatusOK, gin.H{"install_path": result.InstallPath})
+		return
+	}
+
+	c.FileAttachment(result.FilePath, result.FileName)
+}
+
+func (h *SteamDownloaderAPI) runJob(ctx context.Context, job *jobs.Job) (jobs.Result, error) {
+	switch job.Request.Kind {
+	case jobs.KindWorkshop:
+		return h.downloadWorkshop(ctx, job)
+	case jobs.KindCollection:
+		return h.downloadCollection(ctx, job)
+	case jobs.KindApp:
+		return h.downloadApp(ctx, job)
+	case jobs.KindDepot:
+		return h.downloadDepot(ctx, job)
+	default:
+		return jobs.Result{}, fmt.Errorf("unsupported job kind %q", job.Request.Kind)
+	}
+}
+
+func (h *SteamDownloaderAPI) downloadWorkshop(ctx context.Context, job *jobs.Job) (jobs.Result, error) {
+	appID, workshopID := job.Request.AppID, job.Request.ID
+
+	var (
+		workshopName string
+		graph        *steam.DependencyGraph
+		deps         []*steam.WorkshopItem
+		err          error
+	)
+	if job.Request.SkipDependencies {
+		workshopName, err = h.steam.GetWorkshopName(ctx, workshopID)
+	} else if graph, err = h.steam.ResolveDependencies(ctx, workshopID); err == nil {
+		workshopName = graph.Items[workshopID].Title
+		deps = h.dependencies(appID, graph)
+	}
+	if err != nil {
+		return jobs.Result{}, fmt.Errorf("could not find workshop item: %w", err)
+	}
+	h.titles.Store(workshopID, workshopName)
+
+	ids := []int{workshopID}
+	for _, dep := range deps {
+		ids = append(ids, dep.ID)
+	}
+	defer h.content.use(appID, ids...)()
+
+	zipFileName := fmt.Sprintf("%d_%s", workshopID, util.SanitizeFileName(workshopName))
+	if manifestID := job.Request.ManifestID; manifestID != 0 {
+		zipFileName += fmt.Sprintf("_%d", manifestID)
+	}
+	if len(deps) > 0 {
+		zipFileName += "_deps"
+	}
+	zipFileName += platformSuffix(job) + ".zip"
 	zipFilePath := filepath.Join(h.saveDirectory, zipFileName)
+	result := jobs.Result{FilePath: zipFilePath, FileName: zipFileName}
 
 	if _, err := os.Stat(zipFilePath); !os.IsNotExist(err) {
-		c.FileAttachment(zipFilePath, zipFileName)
-		return
+		return result, nil
+	}
+
+	log.Printf("⬇️ Starting download for AppID: %d, WorkshopID: %d", appID, workshopID)
+
+	var sourcePath string
+	if job.Request.ManifestID != 0 {
+		md, ok := h.downloader.(downloader.ManifestDownloader)
+		if !ok {
+			return jobs.Result{}, downloader.ErrManifestUnsupported
+		}
+		sourcePath, err = md.DownloadWorkshopManifest(ctx, appID, workshopID, h.depotOptions(job, workshopID))
+	} else {
+		err = h.downloader.DownloadWorkshopItem(ctx, appID, workshopID, h.downloadOptions(job, true))
+		sourcePath = h.downloader.GetWorkshopContentPath(appID, workshopID, steamcmd.Platform(job.Request.Platform))
+	}
+	if err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to download item: %w", err)
+	}
+
+	if len(deps) == 0 {
+		log.Printf("✅ Downloaded AppID: %d, WorkshopID: %d. Now zipping...", appID, workshopID)
+
+		job.SetState(jobs.StateArchiving)
+
+		if err := util.ZipDirectory(sourcePath, zipFilePath, archiveProgress(job)); err != nil {
+			return jobs.Result{}, fmt.Errorf("failed to create zip archive: %w", err)
+		}
+		log.Printf("📦 Zipped successfully: %s", zipFileName)
+
+		return result, nil
+	}
+
+	if err := h.downloadDependencies(ctx, job, deps); err != nil {
+		return jobs.Result{}, err
+	}
+	log.Printf("✅ Downloaded AppID: %d, WorkshopID: %d and %d dependencies. Now zipping...", appID, workshopID, len(deps))
+
+	job.SetState(jobs.StateArchiving)
+
+	manifestPath, err := writeDependencyManifest(h.saveDirectory, graph)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+	defer os.Remove(manifestPath)
+
+	sources := []util.ZipSource{
+		{Path: sourcePath, Alias: fmt.Sprintf("%d_%s", workshopID, util.SanitizeFileName(workshopName))},
+		{Path: manifestPath, Alias: "dependencies.json"},
+	}
+	for _, dep := range deps {
+		sources = append(sources, util.ZipSource{
+			Path:  h.downloader.GetWorkshopContentPath(appID, dep.ID, steamcmd.Platform(job.Request.Platform)),
+			Alias: fmt.Sprintf("%d_%s", dep.ID, util.SanitizeFileName(dep.Title)),
+		})
+	}
+
+	if err := util.ZipMultipleDirectories(sources, zipFilePath, archiveProgress(job)); err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to create zip archive: %w", err)
+	}
+	log.Printf("📦 Zipped successfully: %s", zipFileName)
+
+	return result, nil
+}
+
+// dependencies returns the items of graph that have to be downloaded besides
+// the root. Items of other apps cannot be fetched under appID and are skipped.
+func (h *SteamDownloaderAPI) dependencies(appID int, graph *steam.DependencyGraph) []*steam.WorkshopItem {
+	for _, id := range graph.Missing {
+		log.Printf("⚠️ Required item %d of WorkshopID: %d could not be found, skipping", id, graph.Root)
+	}
+
+	var deps []*steam.WorkshopItem
+	for _, id := range graph.Order[1:] {
+		item := graph.Items[id]
+		if item.AppID != 0 && item.AppID != appID {
+			log.Printf("⚠️ Required item %d of WorkshopID: %d belongs to AppID: %d, skipping", id, graph.Root, item.AppID)
+			continue
+		}
+		h.titles.Store(item.ID, item.Title)
+		deps = append(deps, item)
+	}
+	return deps
+}
+
+// downloadDependencies fails if any dependency could not be downloaded, since
+// an incomplete set is what the dependencies are fetched to prevent.
+func (h *SteamDownloaderAPI) downloadDependencies(ctx context.Context, job *jobs.Job, deps []*steam.WorkshopItem) error {
+	ids := make([]int, 0, len(deps))
+	for _, dep := range deps {
+		ids = append(ids, dep.ID)
+	}
+
+	log.Printf("⬇️ Downloading %d dependencies of WorkshopID: %d", len(ids), job.Request.ID)
+
+	var errs []error
+	for _, r := range h.downloader.DownloadWorkshopItems(ctx, job.Request.AppID, ids, h.downloadOptions(job, true)) {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to download dependency %d: %w", r.WorkshopID, r.Err))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return context.Cause(ctx)
+	}
+	return errors.Join(errs...)
+}
+
+func writeDependencyManifest(dir string, graph *steam.DependencyGraph) (string, error) {
+	data, err := json.MarshalIndent(graph.Tree(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(dir, "dependencies-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to write dependency manifest: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write dependency manifest: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// collectionsDir keeps collection archives apart from item archives, whose
+// names they could otherwise share.
+const collectionsDir = "collections"
+
+func (h *SteamDownloaderAPI) downloadCollection(ctx context.Context, job *jobs.Job) (jobs.Result, error) {
+	appID, collectionID := job.Request.AppID, job.Request.ID
+
+	log.Printf("⬇️ Starting download for CollectionID: %d", collectionID)
+
+	collection, err := h.steam.GetCollection(ctx, collectionID)
+	if err != nil {
+		return jobs.Result{}, fmt.Errorf("could not get collection items: %w", err)
 	}
 
-	log.Printf("Collection '%s' contains %d items.", collectionTitle, len(items))
+	entries := collectionEntries(appID, collection, "")
+	if len(entries) == 0 {
+		return jobs.Result{}, fmt.Errorf("%w: collection %d is empty", steam.ErrNotFound, collectionID)
+	}
+
+	zipFileName := fmt.Sprintf("%d_%s_collection%s.zip", collectionID, util.SanitizeFileName(collection.Title), platformSuffix(job))
+	zipFilePath := filepath.Join(h.saveDirectory, collectionsDir, zipFileName)
+	result := jobs.Result{FilePath: zipFilePath, FileName: zipFileName}
+
+	if _, err := os.Stat(zipFilePath); !os.IsNotExist(err) {
+		return result, nil
+	}
+
+	// Items linked from several collections are downloaded once, in batches
+	// per app.
+	type batch struct {
+		appID int
+		ids   []int
+	}
+	titles := make(map[int]string, len(entries))
+	byApp := make(map[int][]int)
+	var apps []int
+	for _, entry := range entries {
+		if _, ok := titles[entry.item.ID]; ok {
+			continue
+		}
+		titles[entry.item.ID] = entry.item.Title
+		h.titles.Store(entry.item.ID, entry.item.Title)
+
+		if _, ok := byApp[entry.appID]; !ok {
+			apps = append(apps, entry.appID)
+		}
+		byApp[entry.appID] = append(byApp[entry.appID], entry.item.ID)
+	}
+
+	log.Printf("Collection '%s' contains %d items across %d apps.", collection.Title, len(titles), len(apps))
+
+	for _, itemApp := range apps {
+		defer h.content.use(itemApp, byApp[itemApp]...)()
+	}
 
 	bar := progressbar.Default(
-		int64(len(items)),
+		int64(len(titles)),
 		"Downloading collection items",
 	)
 
-	const maxWorkers = 5
-	var wg sync.WaitGroup
-	itemChan := make(chan steam.WorkshopItem, len(items))
+	opts := h.downloadOptions(job, false)
+	publishResult := opts.OnResult
+	opts.OnResult = func(r steamcmd.ItemResult) {
+		publishResult(r)
+		if r.Err != nil {
+			log.Printf("   ⚠️ Failed to download item %d (%s): %v\n", r.WorkshopID, titles[r.WorkshopID], r.Err)
+			return
+		}
+		bar.Add(1)
+	}
 
-	for i := 0; i < maxWorkers; i++ {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	batchChan := make(chan batch)
+
+	for i := 0; i < h.downloader.Size(); i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for item := range itemChan {
-				if err := h.steamcmd.DownloadWorkshopItem(appID, item.ID, false); err != nil {
-					log.Printf("   ⚠️ Failed to download item %d (%s): %v\n", item.ID, item.Title, err)
-					continue
+			for b := range batchChan {
+				for _, r := range h.downloader.DownloadWorkshopItems(ctx, b.appID, b.ids, opts) {
+					if r.Err != nil {
+						mu.Lock()
+						errs = append(errs, fmt.Errorf("failed to download item %d: %w", r.WorkshopID, r.Err))
+						mu.Unlock()
+					}
 				}
-				bar.Add(1)
 			}
 		}()
 	}
 
-	for _, item := range items {
-		itemChan <- item
+	for _, itemApp := range apps {
+		ids := byApp[itemApp]
+		for start := 0; start < len(ids); start += h.batchSize {
+			end := min(start+h.batchSize, len(ids))
+			batchChan <- batch{appID: itemApp, ids: ids[start:end]}
+		}
 	}
 
-	close(itemChan)
+	close(batchChan)
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return jobs.Result{}, context.Cause(ctx)
+	}
+	// An incomplete archive would be served from the cache from now on, so
+	// the job fails and the next request retries the missing items.
+	if len(errs) > 0 {
+		return jobs.Result{}, fmt.Errorf("%d of %d collection items could not be downloaded: %w", len(errs), len(titles), errors.Join(errs...))
+	}
+
 	log.Println("✅ All collection items downloaded. Now zipping...")
 
+	job.SetState(jobs.StateArchiving)
+
 	var contentPaths []util.ZipSource
-	for _, item := range items {
+	for _, entry := range entries {
 		contentPaths = append(contentPaths, util.ZipSource{
-			Path:  h.steamcmd.GetWorkshopContentPath(appID, item.ID),
-			Alias: fmt.Sprintf("%d_%s", item.ID, util.SanitizeFileName(item.Title)),
+			Path:  h.downloader.GetWorkshopContentPath(entry.appID, entry.item.ID, steamcmd.Platform(job.Request.Platform)),
+			Alias: entry.alias,
 		})
 	}
 
-	if err := util.ZipMultipleDirectories(contentPaths, zipFilePath); err != nil {
-		c.String(http.StatusInternalServerError, "Failed to create collection zip: %v", err)
-		return
+	if err := util.ZipMultipleDirectories(contentPaths, zipFilePath, archiveProgress(job)); err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to create collection zip: %w", err)
 	}
 	log.Printf("📦 Zipped collection successfully: %s", zipFileName)
 
-	c.FileAttachment(zipFilePath, zipFileName)
+	return result, nil
+}
+
+// collectionEntry is an item of a collection tree and its folder in the
+// archive.
+type collectionEntry struct {
+	appID int
+	item  steam.WorkshopItem
+	alias string
+}
+
+// collectionEntries flattens a collection. Items of linked collections are
+// placed in a folder per collection, and items without a known app are
+// assumed to belong to appID.
+func collectionEntries(appID int, collection *steam.Collection, dir string) []collectionEntry {
+	var entries []collectionEntry
+	for _, item := range collection.Items {
+		itemApp := item.AppID
+		if itemApp == 0 {
+			itemApp = appID
+		}
+		entries = append(entries, collectionEntry{
+			appID: itemApp,
+			item:  item,
+			alias: filepath.Join(dir, fmt.Sprintf("%d_%s", item.ID, util.SanitizeFileName(item.Title))),
+		})
+	}
+
+	for _, linked := range collection.Collections {
+		linkedDir := filepath.Join(dir, fmt.Sprintf("%d_%s", linked.ID, util.SanitizeFileName(linked.Title)))
+		entries = append(entries, collectionEntries(appID, linked, linkedDir)...)
+	}
+	return entries
+}
+
+// downloadApp always runs app_update so repeated requests pick up new builds.
+// Without an install_dir the result is archived like workshop content.
+func (h *SteamDownloaderAPI) downloadApp(ctx context.Context, job *jobs.Job) (jobs.Result, error) {
+	req := job.Request
+
+	installDir, err := h.resolveInstallDir(req.InstallDir)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+
+	log.Printf("⬇️ Starting app_update for AppID: %d", req.AppID)
+
+	installPath, err := h.steamcmd.DownloadApp(ctx, req.AppID, steamcmd.AppOptions{
+		Beta:         req.Beta,
+		BetaPassword: req.BetaPassword,
+		Validate:     true,
+		Platform:     steamcmd.Platform(req.Platform),
+		InstallDir:   installDir,
+		OnProgress: func(percent float64) {
+			job.Publish(jobs.Event{Type: jobs.EventItemProgress, ItemID: req.AppID, Percent: percent})
+		},
+	})
+	if err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to download app: %w", err)
+	}
+
+	if manifest, err := steamcmd.ReadAppManifest(steamcmd.AppManifestPath(installPath, req.AppID)); err == nil {
+		log.Printf("ℹ️ AppID: %d is at build %d (%d bytes)", req.AppID, manifest.BuildID, manifest.SizeOnDisk)
+	}
+
+	if installDir != "" {
+		log.Printf("✅ Installed AppID: %d into %s", req.AppID, installPath)
+		return jobs.Result{InstallPath: installPath}, nil
+	}
+
+	log.Printf("✅ Downloaded AppID: %d. Now zipping...", req.AppID)
+
+	job.SetState(jobs.StateArchiving)
+
+	zipFileName := fmt.Sprintf("app_%d%s.zip", req.AppID, platformSuffix(job))
+	if req.Beta != "" {
+		zipFileName = fmt.Sprintf("app_%d_%s%s.zip", req.AppID, util.SanitizeFileName(req.Beta), platformSuffix(job))
+	}
+	zipFilePath := filepath.Join(h.saveDirectory, zipFileName)
+
+	if err := util.ZipDirectory(installPath, zipFilePath, archiveProgress(job)); err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to create zip archive: %w", err)
+	}
+	log.Printf("📦 Zipped successfully: %s", zipFileName)
+
+	return jobs.Result{FilePath: zipFilePath, FileName: zipFileName}, nil
+}
+
+func (h *SteamDownloaderAPI) downloadDepot(ctx context.Context, job *jobs.Job) (jobs.Result, error) {
+	appID, depotID, manifestID := job.Request.AppID, job.Request.ID, job.Request.ManifestID
+
+	zipFileName := fmt.Sprintf("depot_%d_%d%s.zip", depotID, manifestID, platformSuffix(job))
+	if manifestID == 0 {
+		zipFileName = fmt.Sprintf("depot_%d_latest%s.zip", depotID, platformSuffix(job))
+	}
+	zipFilePath := filepath.Join(h.saveDirectory, zipFileName)
+	result := jobs.Result{FilePath: zipFilePath, FileName: zipFileName}
+
+	// Only a pinned manifest is immutable; the latest build is refetched.
+	if _, err := os.Stat(zipFilePath); manifestID != 0 && !os.IsNotExist(err) {
+		return result, nil
+	}
+
+	log.Printf("⬇️ Starting download for AppID: %d, DepotID: %d, ManifestID: %d", appID, depotID, manifestID)
+
+	sourcePath, err := h.steamcmd.DownloadDepot(ctx, appID, depotID, h.depotOptions(job, depotID))
+	if err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to download depot: %w", err)
+	}
+	log.Printf("✅ Downloaded AppID: %d, DepotID: %d. Now zipping...", appID, depotID)
+
+	job.SetState(jobs.StateArchiving)
+
+	if err := util.ZipDirectory(sourcePath, zipFilePath, archiveProgress(job)); err != nil {
+		return jobs.Result{}, fmt.Errorf("failed to create zip archive: %w", err)
+	}
+	log.Printf("📦 Zipped successfully: %s", zipFileName)
+
+	return result, nil
+}
+
+// prepareRequest validates the parts of req that depend on server
+// configuration and applies the app's default platform, so equivalent
+// requests share a job.
+func (h *SteamDownloaderAPI) prepareRequest(req *jobs.Request) error {
+	platform, err := steamcmd.ParsePlatform(req.Platform)
+	if err != nil {
+		return err
+	}
+	if platform == "" {
+		platform = h.appPlatforms[req.AppID]
+	}
+	req.Platform = string(platform)
+
+	_, err = h.resolveInstallDir(req.InstallDir)
+	return err
+}
+
+// platformSuffix keeps archives of different platform variants apart.
+func platformSuffix(job *jobs.Job) string {
+	if job.Request.Platform == "" {
+		return ""
+	}
+	return "_" + job.Request.Platform
+}
+
+// resolveInstallDir maps a requested install_dir onto h.appInstallDir,
+// rejecting paths that would escape it.
+func (h *SteamDownloaderAPI) resolveInstallDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	if h.appInstallDir == "" {
+		return "", errors.New("install_dir is disabled on this server")
+	}
+
+	root, err := filepath.Abs(h.appInstallDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve app install root: %w", err)
+	}
+
+	path := filepath.Join(root, dir)
+	if rel, err := filepath.Rel(root, path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("install_dir %q must be a subdirectory of the app install root", dir)
+	}
+
+	return path, nil
+}
+
+func (h *SteamDownloaderAPI) downloadOptions(job *jobs.Job, validate bool) steamcmd.DownloadOptions {
+	return steamcmd.DownloadOptions{
+		Validate:    validate,
+		Platform:    steamcmd.Platform(job.Request.Platform),
+		ItemTimeout: h.itemTimeout,
+		OnStart: func(workshopID int) {
+			job.Publish(jobs.Event{Type: jobs.EventItemStarted, ItemID: workshopID})
+		},
+		OnProgress: func(workshopID int, percent float64) {
+			job.Publish(jobs.Event{Type: jobs.EventItemProgress, ItemID: workshopID, Percent: percent})
+		},
+		OnResult: func(r steamcmd.ItemResult) {
+			if r.Err != nil {
+				job.Publish(jobs.Event{Type: jobs.EventItemFailed, ItemID: r.WorkshopID, Error: r.Err.Error()})
+				return
+			}
+			job.Publish(jobs.Event{Type: jobs.EventItemFinished, ItemID: r.WorkshopID, Percent: 100})
+		},
+	}
+}
+
+func (h *SteamDownloaderAPI) depotOptions(job *jobs.Job, itemID int) steamcmd.DepotOptions {
+	return steamcmd.DepotOptions{
+		ManifestID: job.Request.ManifestID,
+		Platform:   steamcmd.Platform(job.Request.Platform),
+		OnProgress: func(percent float64) {
+			job.Publish(jobs.Event{Type: jobs.EventItemProgress, ItemID: itemID, Percent: percent})
+		},
+	}
+}
+
+func archiveProgress(job *jobs.Job) util.ProgressFunc {
+	return func(bytesWritten int64) {
+		job.Publish(jobs.Event{Type: jobs.EventArchiveProgress, BytesWritten: bytesWritten})
+	}
 }
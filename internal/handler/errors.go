@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/jobs"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/gin-gonic/gin"
+)
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, downloader.ErrManifestUnsupported):
+		return http.StatusBadRequest
+	case errors.Is(err, steamcmd.ErrItemNotFound), errors.Is(err, steamcmd.ErrManifestNotFound),
+		errors.Is(err, steam.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, steamcmd.ErrAccessDenied), errors.Is(err, steamcmd.ErrNoSubscription),
+		errors.Is(err, steamcmd.ErrNoAccount):
+		return http.StatusForbidden
+	case errors.Is(err, steamcmd.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, steamcmd.ErrTimeout), errors.Is(err, jobs.ErrJobTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, jobs.ErrCanceled):
+		return http.StatusConflict
+	case errors.Is(err, steamcmd.ErrLoginFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func respondError(c *gin.Context, err error) {
+	body := gin.H{"error": err.Error()}
+
+	var coded *steamcmd.Error
+	if errors.As(err, &coded) {
+		body["code"] = coded.Code()
+	}
+
+	c.JSON(errorStatus(err), body)
+}
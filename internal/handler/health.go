@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *SteamDownloaderAPI) HealthHandler(c *gin.Context) {
+	var statuses map[string]error
+	if r, ok := h.downloader.(interface {
+		Statuses(ctx context.Context) map[string]error
+	}); ok {
+		statuses = r.Statuses(c.Request.Context())
+	} else {
+		statuses = map[string]error{"steamcmd": h.downloader.Health(c.Request.Context())}
+	}
+
+	status := http.StatusOK
+	backends := make(gin.H, len(statuses))
+	for name, err := range statuses {
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			backends[name] = gin.H{"healthy": false, "error": err.Error()}
+			continue
+		}
+		backends[name] = gin.H{"healthy": true}
+	}
+
+	c.JSON(status, gin.H{"healthy": status == http.StatusOK, "backends": backends})
+}
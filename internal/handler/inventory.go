@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+var archiveNameRegex = regexp.MustCompile(`^(\d+)_.*\.zip$`)
+
+var errItemInUse = errors.New("workshop item is being downloaded or archived, try again later")
+
+type inventoryItem struct {
+	AppID       int       `json:"app_id"`
+	WorkshopID  int       `json:"workshop_id"`
+	Title       string    `json:"title,omitempty"`
+	SizeOnDisk  int64     `json:"size_on_disk"`
+	TimeUpdated time.Time `json:"time_updated"`
+	ManifestID  uint64    `json:"manifest_id,string"`
+	Platform    string    `json:"platform,omitempty"`
+	// Archives are ready-made zips of the item that are served without
+	// downloading it again.
+	Archives     []string `json:"archives"`
+	ArchiveReady bool     `json:"archive_ready"`
+}
+
+func (h *SteamDownloaderAPI) InventoryHandler(c *gin.Context) {
+	apps, err := h.contentInventory().WorkshopApps()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := []inventoryItem{}
+	for _, appID := range apps {
+		appItems, err := h.inventory(c.Request.Context(), appID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		items = append(items, appItems...)
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *SteamDownloaderAPI) AppInventoryHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Param("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
+
+	items, err := h.inventory(c.Request.Context(), appID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *SteamDownloaderAPI) DeleteInventoryItemHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Param("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
+
+	workshopID, err := strconv.Atoi(c.Param("workshop_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workshop ID"})
+		return
+	}
+
+	removed, err := h.removeItem(appID, workshopID)
+	if errors.Is(err, errItemInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "workshop item is not installed"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SteamDownloaderAPI) DeleteAppInventoryHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Param("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
+
+	items, err := h.contentInventory().InstalledWorkshopItems(appID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	removed := []int{}
+	for _, item := range items {
+		if slices.Contains(removed, item.WorkshopID) {
+			continue
+		}
+		if _, err := h.removeItem(appID, item.WorkshopID); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errItemInUse) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error(), "removed": removed})
+			return
+		}
+		removed = append(removed, item.WorkshopID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
+// contentInventory is the inventory of every download backend, or of the
+// steamcmd pool if the downloader keeps none.
+func (h *SteamDownloaderAPI) contentInventory() downloader.Inventory {
+	if inv, ok := h.downloader.(downloader.Inventory); ok {
+		return inv
+	}
+	return h.steamcmd
+}
+
+func (h *SteamDownloaderAPI) inventory(ctx context.Context, appID int) ([]inventoryItem, error) {
+	installed, err := h.contentInventory().InstalledWorkshopItems(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	archives, err := h.itemArchives()
+	if err != nil {
+		return nil, err
+	}
+
+	titles := h.inventoryTitles(ctx, installed)
+
+	items := make([]inventoryItem, 0, len(installed))
+	for _, item := range installed {
+		size, err := util.DirSize(item.Path)
+		if err != nil {
+			size = item.Size
+		}
+
+		name := titles[item.WorkshopID]
+		itemArchives := platformArchives(archives[item.WorkshopID], item.Platform)
+
+		items = append(items, inventoryItem{
+			AppID:        appID,
+			WorkshopID:   item.WorkshopID,
+			Title:        name,
+			SizeOnDisk:   size,
+			TimeUpdated:  item.TimeUpdated,
+			ManifestID:   item.ManifestID,
+			Platform:     string(item.Platform),
+			Archives:     itemArchives,
+			ArchiveReady: len(itemArchives) > 0,
+		})
+	}
+
+	return items, nil
+}
+
+// itemArchives maps workshop IDs to the single item archives in the save
+// directory. Collection archives are kept in collectionsDir.
+func (h *SteamDownloaderAPI) itemArchives() (map[int][]string, error) {
+	entries, err := os.ReadDir(h.saveDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	archives := make(map[int][]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		m := archiveNameRegex.FindStringSubmatch(name)
+		if m == nil || entry.IsDir() {
+			continue
+		}
+		id, _ := strconv.Atoi(m[1])
+		archives[id] = append(archives[id], name)
+	}
+	return archives, nil
+}
+
+// platformArchives picks the archives built for platform, which end in the
+// platform suffix of the download.
+func platformArchives(names []string, platform steamcmd.Platform) []string {
+	matching := []string{}
+	for _, name := range names {
+		var suffix steamcmd.Platform
+		for _, p := range steamcmd.Platforms[1:] {
+			if strings.HasSuffix(name, "_"+string(p)+".zip") {
+				suffix = p
+			}
+		}
+		if suffix == platform {
+			matching = append(matching, name)
+		}
+	}
+	return matching
+}
+
+// inventoryTitles maps the items to the titles seen while downloading them,
+// looking up the others in the metadata client, which caches them.
+func (h *SteamDownloaderAPI) inventoryTitles(ctx context.Context, items []steamcmd.InstalledItem) map[int]string {
+	titles := make(map[int]string, len(items))
+	var unknown []int
+	for _, item := range items {
+		if _, ok := titles[item.WorkshopID]; ok {
+			continue
+		}
+		if title, ok := h.titles.Load(item.WorkshopID); ok {
+			titles[item.WorkshopID] = title.(string)
+			continue
+		}
+		titles[item.WorkshopID] = ""
+		unknown = append(unknown, item.WorkshopID)
+	}
+	if len(unknown) == 0 {
+		return titles
+	}
+
+	found, err := h.steam.GetWorkshopItems(ctx, unknown)
+	if err != nil {
+		log.Printf("⚠️ Could not look up titles of %d installed items: %v", len(unknown), err)
+		return titles
+	}
+	for _, item := range found {
+		if item != nil {
+			titles[item.ID] = item.Title
+			h.titles.Store(item.ID, item.Title)
+		}
+	}
+	return titles
+}
+
+// removeItem deletes an item's content and archives and reports whether
+// anything was removed. Items a job is working on are left alone.
+func (h *SteamDownloaderAPI) removeItem(appID, workshopID int) (bool, error) {
+	unlock, ok := h.content.tryLock(appID, workshopID)
+	if !ok {
+		return false, errItemInUse
+	}
+	defer unlock()
+
+	removed, err := h.contentInventory().RemoveWorkshopItem(appID, workshopID)
+	if err != nil {
+		return removed, err
+	}
+
+	archives, err := h.itemArchives()
+	if err != nil {
+		return removed, err
+	}
+	for _, name := range archives[workshopID] {
+		if err := os.Remove(filepath.Join(h.saveDirectory, name)); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove archive %s: %w", name, err)
+		}
+		removed = true
+	}
+
+	return removed, nil
+}
+
+// contentLocks guards workshop content on disk. Jobs hold read locks on the
+// items they download and archive, removing an item takes the write lock.
+type contentLocks struct {
+	mu    sync.Mutex
+	locks map[[2]int]*contentLock
+}
+
+// contentLock counts the holders and waiters of an item's lock, so it can be
+// dropped once nobody uses it.
+type contentLock struct {
+	sync.RWMutex
+	refs int
+}
+
+func (l *contentLocks) acquire(key [2]int) *contentLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks == nil {
+		l.locks = make(map[[2]int]*contentLock)
+	}
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &contentLock{}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+func (l *contentLocks) release(key [2]int, lock *contentLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, key)
+	}
+}
+
+// use read locks the items until the returned function is called. Removals
+// never wait for the write lock, so jobs sharing items cannot deadlock.
+func (l *contentLocks) use(appID int, workshopIDs ...int) func() {
+	keys := make([][2]int, 0, len(workshopIDs))
+	held := make([]*contentLock, 0, len(workshopIDs))
+	for _, id := range workshopIDs {
+		key := [2]int{appID, id}
+		lock := l.acquire(key)
+		lock.RLock()
+		keys = append(keys, key)
+		held = append(held, lock)
+	}
+	return func() {
+		for i, lock := range held {
+			lock.RUnlock()
+			l.release(keys[i], lock)
+		}
+	}
+}
+
+func (l *contentLocks) tryLock(appID, workshopID int) (func(), bool) {
+	key := [2]int{appID, workshopID}
+	lock := l.acquire(key)
+	if !lock.TryLock() {
+		l.release(key, lock)
+		return nil, false
+	}
+	return func() {
+		lock.Unlock()
+		l.release(key, lock)
+	}, true
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestItemArchives(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"1_Map.zip",
+		"1_Map_deps_windows.zip",
+		"2_best_collection.zip",
+		"app_4020.zip",
+		"dependencies-123.json",
+		filepath.Join(collectionsDir, "3_Maps_collection.zip"),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &SteamDownloaderAPI{saveDirectory: dir}
+	archives, err := h.itemArchives()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int][]string{
+		1: {"1_Map.zip", "1_Map_deps_windows.zip"},
+		2: {"2_best_collection.zip"},
+	}
+	if len(archives) != len(want) {
+		t.Errorf("archives = %v, want %v", archives, want)
+	}
+	for id, names := range want {
+		if !slices.Equal(archives[id], names) {
+			t.Errorf("archives of %d = %v, want %v", id, archives[id], names)
+		}
+	}
+}
+
+func TestContentLocks(t *testing.T) {
+	var l contentLocks
+
+	release := l.use(4000, 1, 2)
+	shared := l.use(4000, 1)
+	if _, ok := l.tryLock(4000, 1); ok {
+		t.Fatal("removed an item a job is using")
+	}
+	unlock, ok := l.tryLock(4000, 3)
+	if !ok {
+		t.Fatal("could not remove an unused item")
+	}
+	unlock()
+
+	release()
+	if _, ok := l.tryLock(4000, 1); ok {
+		t.Fatal("removed an item another job is still using")
+	}
+	shared()
+
+	unlock, ok = l.tryLock(4000, 1)
+	if !ok {
+		t.Fatal("could not remove an item after its jobs finished")
+	}
+	unlock()
+
+	if n := len(l.locks); n != 0 {
+		t.Errorf("%d locks are kept after their last use", n)
+	}
+}
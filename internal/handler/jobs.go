@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *SteamDownloaderAPI) CreateJobHandler(c *gin.Context) {
+	var req jobs.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.prepareRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.jobs.Submit(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job.Status())
+}
+
+func (h *SteamDownloaderAPI) GetJobHandler(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job.Status())
+}
+
+func (h *SteamDownloaderAPI) CancelJobHandler(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	if !h.jobs.Cancel(job.ID) {
+		c.JSON(http.StatusConflict, job.Status())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job.Status())
+}
+
+func (h *SteamDownloaderAPI) GetJobResultHandler(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	switch job.State() {
+	case jobs.StateDone:
+		result, _ := job.Result()
+		if result.FilePath == "" {
+			c.JSON(http.StatusOK, job.Status())
+			return
+		}
+		c.FileAttachment(result.FilePath, result.FileName)
+	case jobs.StateFailed:
+		_, err := job.Result()
+		c.JSON(errorStatus(err), job.Status())
+	default:
+		c.JSON(http.StatusConflict, job.Status())
+	}
+}
+
+func (h *SteamDownloaderAPI) JobEventsHandler(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+
+	history, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, ev := range history {
+		c.SSEvent(string(ev.Type), ev)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				c.SSEvent("end", job.Status())
+				return false
+			}
+			c.SSEvent(string(ev.Type), ev)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -8,10 +8,16 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/jobs"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
 	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
 	"github.com/gin-gonic/gin"
 )
@@ -20,17 +26,79 @@ var (
 	infoRegex = regexp.MustCompile(`(?i)(?:SubscribeItem|SubscribeCollection|SubscribeCollectionItem)\(\s*'(\d+)',\s*'(\d+)'\s*\);`)
 )
 
+type Config struct {
+	Jobs jobs.Config
+	// Downloader handles workshop downloads; it defaults to the steamcmd
+	// pool, which always serves app and depot downloads.
+	Downloader downloader.Downloader
+	// Steam looks up workshop metadata; it defaults to steam.NewClient().
+	Steam *steam.Client
+	// BatchSize is the number of collection items downloaded per steamcmd session.
+	BatchSize   int
+	ItemTimeout time.Duration
+	SteamGuard  *steamcmd.GuardBroker
+	AdminToken  string
+	// AppInstallRoot confines the install_dir of app downloads. Installing
+	// into a directory is disabled when empty.
+	AppInstallRoot string
+	// AppPlatforms is the platform content is downloaded for per app ID,
+	// unless a request asks for another one.
+	AppPlatforms map[int]steamcmd.Platform
+	// HTTPClient's transport carries the proxied steamcommunity.com
+	// requests; the default transport is used when nil.
+	HTTPClient *http.Client
+}
+
 type SteamDownloaderAPI struct {
-	steamcmd      *steamcmd.SteamCMD
+	steamcmd      *steamcmd.Pool
+	downloader    downloader.Downloader
+	steam         *steam.Client
+	jobs          *jobs.Manager
 	saveDirectory string
+	batchSize     int
+	itemTimeout   time.Duration
+	steamGuard    *steamcmd.GuardBroker
+	adminToken    string
+	appInstallDir string
+	appPlatforms  map[int]steamcmd.Platform
+	httpClient    *http.Client
+	// content keeps removals away from items jobs are working on.
+	content contentLocks
+	// titles remembers workshop item titles seen while downloading.
+	titles sync.Map
 }
 
-func New(s *steamcmd.SteamCMD) *SteamDownloaderAPI {
+func New(s *steamcmd.Pool, cfg Config) *SteamDownloaderAPI {
 	temp, err := os.MkdirTemp("", "steam-downloader-")
 	if err != nil {
 		panic(err)
 	}
-	return &SteamDownloaderAPI{steamcmd: s, saveDirectory: temp}
+	if err := os.Mkdir(filepath.Join(temp, collectionsDir), 0755); err != nil {
+		panic(err)
+	}
+
+	h := &SteamDownloaderAPI{
+		steamcmd:      s,
+		downloader:    cfg.Downloader,
+		steam:         cfg.Steam,
+		saveDirectory: temp,
+		batchSize:     max(cfg.BatchSize, 1),
+		itemTimeout:   cfg.ItemTimeout,
+		steamGuard:    cfg.SteamGuard,
+		adminToken:    cfg.AdminToken,
+		appInstallDir: cfg.AppInstallRoot,
+		appPlatforms:  cfg.AppPlatforms,
+		httpClient:    cfg.HTTPClient,
+	}
+	if h.downloader == nil {
+		h.downloader = s
+	}
+	if h.steam == nil {
+		h.steam = steam.NewClient()
+	}
+	h.jobs = jobs.NewManager(cfg.Jobs, h.runJob)
+
+	return h
 }
 
 func (h *SteamDownloaderAPI) UnsupportedPageHandler(c *gin.Context) {
@@ -40,6 +108,9 @@ func (h *SteamDownloaderAPI) UnsupportedPageHandler(c *gin.Context) {
 func (h *SteamDownloaderAPI) SteamProxyHandler(c *gin.Context) {
 	remote, _ := url.Parse("https://steamcommunity.com")
 	proxy := httputil.NewSingleHostReverseProxy(remote)
+	if h.httpClient != nil {
+		proxy.Transport = h.httpClient.Transport
+	}
 
 	proxy.Director = func(req *http.Request) {
 		req.Header = c.Request.Header
@@ -214,5 +285,6 @@ func (h *SteamDownloaderAPI) SteamProxyHandler(c *gin.Context) {
 }
 
 func (h *SteamDownloaderAPI) Cleanup() {
+	h.jobs.Close()
 	_ = os.RemoveAll(h.saveDirectory)
 }
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *SteamDownloaderAPI) SearchHandler(c *gin.Context) {
+	appID, err := strconv.Atoi(c.Query("app_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+		return
+	}
+
+	page := 1
+	if value := c.Query("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return
+		}
+	}
+
+	query := steam.SearchQuery{
+		AppID: appID,
+		Text:  c.Query("q"),
+		Sort:  c.Query("sort"),
+		Page:  page,
+	}
+	for _, tag := range strings.Split(c.Query("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			query.Tags = append(query.Tags, tag)
+		}
+	}
+	if err := query.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.steam.Search(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
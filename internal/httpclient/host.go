@@ -0,0 +1,93 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// host holds the rate limit and concurrency state of one upstream host.
+type host struct {
+	// interval is the minimum time between the start of two requests.
+	interval time.Duration
+	slots    chan struct{}
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (h *host) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+	release := func() {
+		if h.slots != nil {
+			<-h.slots
+		}
+	}
+
+	if err := h.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// wait blocks until the host's rate limit allows another request.
+func (h *host) wait(ctx context.Context) error {
+	if h.interval <= 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	now := time.Now()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(h.interval)
+	h.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// releasingBody frees the request's concurrency slot once the caller is done
+// with the response.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,189 @@
+// Package httpclient builds the client used for every outbound request:
+// Steam Web API calls, scraping, the reverse proxy and file downloads.
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Timeout bounds a whole request including reading the body, 0
+	// disables it. It does not apply to the reverse proxy, which only uses
+	// the client's transport.
+	Timeout     time.Duration
+	DialTimeout time.Duration
+	// UserAgent is sent with requests that do not set their own.
+	UserAgent string
+	// Proxy is an http, https, socks5 or socks5h URL. Without one the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	Proxy string
+	// RateLimit is the number of requests per second started per host, 0
+	// meaning unlimited.
+	RateLimit float64
+	// MaxPerHost is the number of requests in flight per host, 0 meaning
+	// unlimited. A request counts until its response body is closed.
+	MaxPerHost int
+	// MaxRetries is how often a request answered with 429 or a 5xx status
+	// is repeated. Requests whose body cannot be replayed are not retried.
+	MaxRetries int
+	// MaxRetryWait caps the delay before a retry, including delays asked
+	// for by Retry-After.
+	MaxRetryWait time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:      30 * time.Second,
+		DialTimeout:  10 * time.Second,
+		UserAgent:    "SteamDownloaderAPI",
+		RateLimit:    10,
+		MaxPerHost:   8,
+		MaxRetries:   3,
+		MaxRetryWait: time.Minute,
+	}
+}
+
+func New(cfg Config) (*http.Client, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+		}
+		base.Proxy = http.ProxyURL(proxy)
+	}
+	if cfg.DialTimeout > 0 {
+		base.DialContext = (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+		base.TLSHandshakeTimeout = cfg.DialTimeout
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &transport{base: base, config: cfg, hosts: make(map[string]*host)},
+	}, nil
+}
+
+// WithoutTimeout returns a client sharing c's transport, and with it the
+// limits, but without an overall timeout, for downloads of any size.
+func WithoutTimeout(c *http.Client) *http.Client {
+	unbounded := *c
+	unbounded.Timeout = 0
+	return &unbounded
+}
+
+type transport struct {
+	base   http.RoundTripper
+	config Config
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.config.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.config.UserAgent)
+	}
+
+	h := t.host(req.URL.Host)
+	for attempt := 0; ; attempt++ {
+		res, err := h.roundTrip(t.base, req)
+		if err != nil || !retryable(res.StatusCode) || attempt >= t.config.MaxRetries {
+			return res, err
+		}
+
+		retry, err := rewind(req)
+		if err != nil {
+			return res, nil
+		}
+
+		delay := retryDelay(res, attempt, t.config.MaxRetryWait)
+		log.Printf("⚠️ %s answered %s, retrying in %s (%d/%d)", req.URL.Host, res.Status, delay, attempt+1, t.config.MaxRetries)
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		res.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, context.Cause(req.Context())
+		}
+		req = retry
+	}
+}
+
+func (t *transport) host(name string) *host {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.hosts[name]
+	if !ok {
+		h = &host{}
+		if t.config.RateLimit > 0 {
+			h.interval = time.Duration(float64(time.Second) / t.config.RateLimit)
+		}
+		if t.config.MaxPerHost > 0 {
+			h.slots = make(chan struct{}, t.config.MaxPerHost)
+		}
+		t.hosts[name] = h
+	}
+	return h
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// rewind returns a copy of req that can be sent again, or an error if its
+// body cannot be replayed.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("request body cannot be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// retryDelay honours Retry-After, in seconds or as a date, and otherwise
+// backs off exponentially from one second.
+func retryDelay(res *http.Response, attempt int, maxWait time.Duration) time.Duration {
+	delay := time.Second << attempt
+	if value := res.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(value); err == nil {
+			delay = time.Until(at)
+		}
+	}
+
+	delay = max(delay, 0)
+	if maxWait > 0 {
+		delay = min(delay, maxWait)
+	}
+	return delay
+}
@@ -0,0 +1,208 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewRejectsProxies(t *testing.T) {
+	tests := []struct {
+		proxy   string
+		wantErr bool
+	}{
+		{"", false},
+		{"http://127.0.0.1:3128", false},
+		{"socks5h://127.0.0.1:1080", false},
+		{"ftp://127.0.0.1", true},
+		{"://broken", true},
+	}
+	for _, tt := range tests {
+		_, err := New(Config{Proxy: tt.proxy})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(Proxy: %q) = %v, want error: %t", tt.proxy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		method     string
+		body       string
+		wantStatus int
+		wantCalls  int
+	}{
+		{"success", []int{200}, 3, http.MethodGet, "", 200, 1},
+		{"retried 503", []int{503, 503, 200}, 3, http.MethodGet, "", 200, 3},
+		{"retried 429", []int{429, 200}, 3, http.MethodGet, "", 200, 2},
+		{"exhausted", []int{500, 500, 500}, 2, http.MethodGet, "", 500, 3},
+		{"client error", []int{404, 200}, 3, http.MethodGet, "", 404, 1},
+		{"replayable body", []int{502, 200}, 3, http.MethodPost, "payload", 200, 2},
+		{"disabled", []int{503, 200}, 0, http.MethodGet, "", 503, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1)) - 1
+				if tt.body != "" {
+					buf := make([]byte, len(tt.body)+1)
+					m, _ := r.Body.Read(buf)
+					if string(buf[:m]) != tt.body {
+						t.Errorf("attempt %d sent body %q, want %q", n+1, buf[:m], tt.body)
+					}
+				}
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses)-1)])
+			}))
+			defer server.Close()
+
+			client, err := New(Config{MaxRetries: tt.maxRetries})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest(tt.method, server.URL, strings.NewReader(tt.body))
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus || int(calls.Load()) != tt.wantCalls {
+				t.Errorf("status %d after %d calls, want %d after %d", res.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		attempt    int
+		maxWait    time.Duration
+		want       time.Duration
+	}{
+		{"backoff", "", 0, time.Minute, time.Second},
+		{"backoff grows", "", 3, time.Minute, 8 * time.Second},
+		{"backoff capped", "", 10, time.Minute, time.Minute},
+		{"seconds", "5", 0, time.Minute, 5 * time.Second},
+		{"seconds capped", "3600", 0, time.Minute, time.Minute},
+		{"negative", "-5", 0, time.Minute, 0},
+		{"past date", "Mon, 01 Jan 2001 00:00:00 GMT", 0, time.Minute, 0},
+		{"no cap", "3600", 0, 0, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				res.Header.Set("Retry-After", tt.retryAfter)
+			}
+			if got := retryDelay(res, tt.attempt, tt.maxWait); got != tt.want {
+				t.Errorf("retryDelay = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	agents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.UserAgent()
+	}))
+	defer server.Close()
+
+	client, _ := New(Config{UserAgent: "SteamDownloaderAPI"})
+
+	res, _ := client.Get(server.URL)
+	res.Body.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("User-Agent", "custom")
+	res, _ = client.Do(req)
+	res.Body.Close()
+
+	if got := <-agents; got != "SteamDownloaderAPI" {
+		t.Errorf("default User-Agent = %q", got)
+	}
+	if got := <-agents; got != "custom" {
+		t.Errorf("explicit User-Agent = %q, want custom", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	client, _ := New(Config{RateLimit: 50})
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		res, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	// The first request starts at once, the other four 20ms apart.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("5 requests at 50/s took %s, want at least 80ms", elapsed)
+	}
+}
+
+func TestMaxPerHost(t *testing.T) {
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		n := active.Add(1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		<-release
+		active.Add(-1)
+	}))
+	defer server.Close()
+
+	client, _ := New(Config{MaxPerHost: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := client.Get(server.URL); err == nil {
+				res.Body.Close()
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrency = %d, want 2", got)
+	}
+}
+
+func TestWaitHonoursContext(t *testing.T) {
+	h := &host{interval: time.Hour}
+	if err := h.wait(context.Background()); err != nil {
+		t.Fatalf("first request waited: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := h.wait(ctx); err == nil {
+		t.Error("wait ignored the context")
+	}
+}
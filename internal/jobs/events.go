@@ -0,0 +1,76 @@
+package jobs
+
+import "time"
+
+type EventType string
+
+const (
+	EventState           EventType = "state"
+	EventItemStarted     EventType = "item_started"
+	EventItemProgress    EventType = "item_progress"
+	EventItemFinished    EventType = "item_finished"
+	EventItemFailed      EventType = "item_failed"
+	EventArchiveProgress EventType = "archive_progress"
+)
+
+type Event struct {
+	Type         EventType `json:"type"`
+	State        State     `json:"state,omitempty"`
+	ItemID       int       `json:"item_id,omitempty"`
+	Percent      float64   `json:"percent,omitempty"`
+	BytesWritten int64     `json:"bytes_written,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+func (j *Job) Publish(ev Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.publishLocked(ev)
+}
+
+func (j *Job) publishLocked(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	j.events = append(j.events, ev)
+	if len(j.events) > maxEventHistory {
+		j.events = j.events[len(j.events)-maxEventHistory:]
+	}
+
+	for ch := range j.subscribers {
+		// Slow subscribers miss intermediate events rather than stalling the download.
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns the events published so far and a channel delivering the
+// ones that follow. The channel is closed once the job finishes; the returned
+// function must be called to stop receiving earlier than that.
+func (j *Job) Subscribe() ([]Event, <-chan Event, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	history := make([]Event, len(j.events))
+	copy(history, j.events)
+
+	ch := make(chan Event, 64)
+	if j.subscribers == nil {
+		close(ch)
+		return history, ch, func() {}
+	}
+	j.subscribers[ch] = struct{}{}
+
+	return history, ch, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
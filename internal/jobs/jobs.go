@@ -0,0 +1,522 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	StateQueued      State = "queued"
+	StateDownloading State = "downloading"
+	StateArchiving   State = "archiving"
+	StateDone        State = "done"
+	StateFailed      State = "failed"
+)
+
+type Kind string
+
+const (
+	KindWorkshop   Kind = "workshop"
+	KindCollection Kind = "collection"
+	KindApp        Kind = "app"
+	KindDepot      Kind = "depot"
+)
+
+const maxEventHistory = 1000
+
+var (
+	ErrQueueFull  = errors.New("job queue is full")
+	ErrCanceled   = errors.New("job canceled")
+	ErrClosed     = errors.New("job manager is shutting down")
+	ErrJobTimeout = errors.New("job exceeded its deadline")
+)
+
+// betaPattern matches the beta names and passwords Steam hands out. Anything
+// else could smuggle extra commands into the steamcmd session.
+var betaPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Request describes a download. ID is the workshop item, collection or depot
+// depending on Kind.
+type Request struct {
+	Kind  Kind `json:"kind"`
+	AppID int  `json:"app_id"`
+	ID    int  `json:"id"`
+	// ManifestID pins a workshop item or depot to a historical build. It is
+	// encoded as a string since it does not fit in a JSON number.
+	ManifestID uint64 `json:"manifest_id,string,omitempty"`
+	// Platform forces content for another operating system, see
+	// steamcmd.Platform.
+	Platform string `json:"platform,omitempty"`
+	// SkipDependencies downloads a workshop item without the items it
+	// requires.
+	SkipDependencies bool `json:"skip_dependencies,omitempty"`
+
+	// Beta, BetaPassword and InstallDir only apply to KindApp.
+	Beta         string `json:"beta,omitempty"`
+	BetaPassword string `json:"beta_password,omitempty"`
+	InstallDir   string `json:"install_dir,omitempty"`
+}
+
+func (r Request) Validate() error {
+	switch r.Kind {
+	case KindWorkshop, KindCollection, KindDepot:
+		if r.ID <= 0 {
+			return errors.New("id must be a positive integer")
+		}
+		if r.ManifestID != 0 && r.Kind == KindCollection {
+			return errors.New("manifest_id is not supported for collections")
+		}
+	case KindApp:
+		if r.Beta != "" && !betaPattern.MatchString(r.Beta) {
+			return errors.New("beta may only contain letters, digits, '.', '_' and '-'")
+		}
+		if r.BetaPassword != "" && !betaPattern.MatchString(r.BetaPassword) {
+			return errors.New("beta password may only contain letters, digits, '.', '_' and '-'")
+		}
+	default:
+		return fmt.Errorf("unsupported job kind %q", r.Kind)
+	}
+	if r.AppID <= 0 {
+		return errors.New("app_id must be a positive integer")
+	}
+	return nil
+}
+
+func (r Request) key() string {
+	if r.Kind == KindApp {
+		return fmt.Sprintf("%s:%d:%s:%q:%q:%q", r.Kind, r.AppID, r.Platform, r.Beta, r.BetaPassword, r.InstallDir)
+	}
+	return fmt.Sprintf("%s:%d:%d:%d:%s:%t", r.Kind, r.AppID, r.ID, r.ManifestID, r.Platform, r.SkipDependencies)
+}
+
+// Result is either an archive (FilePath, FileName) or, for apps installed
+// into a target directory, the InstallPath.
+type Result struct {
+	FilePath    string
+	FileName    string
+	InstallPath string
+}
+
+type Runner func(ctx context.Context, job *Job) (Result, error)
+
+type Status struct {
+	ID          string    `json:"id"`
+	Kind        Kind      `json:"kind"`
+	AppID       int       `json:"app_id"`
+	TargetID    int       `json:"target_id"`
+	ManifestID  uint64    `json:"manifest_id,string,omitempty"`
+	Platform    string    `json:"platform,omitempty"`
+	Beta        string    `json:"beta,omitempty"`
+	State       State     `json:"state"`
+	Error       string    `json:"error,omitempty"`
+	ErrorCode   string    `json:"error_code,omitempty"`
+	FileName    string    `json:"file_name,omitempty"`
+	InstallPath string    `json:"install_path,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Job struct {
+	ID      string
+	Request Request
+
+	mu        sync.RWMutex
+	state     State
+	err       error
+	result    Result
+	createdAt time.Time
+	updatedAt time.Time
+	done      chan struct{}
+
+	events      []Event
+	subscribers map[chan Event]struct{}
+
+	cancel   context.CancelCauseFunc
+	canceled bool
+	// after is closed once the cancelled job this one replaced has stopped.
+	after <-chan struct{}
+	// detached jobs were submitted asynchronously and keep running when
+	// their synchronous waiters go away.
+	detached bool
+	waiters  int
+}
+
+func (j *Job) SetState(state State) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state = state
+	j.updatedAt = time.Now()
+	j.publishLocked(Event{Type: EventState, State: state})
+}
+
+func (j *Job) State() State {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.state
+}
+
+func (j *Job) Result() (Result, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.result, j.err
+}
+
+func (j *Job) Status() Status {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	status := Status{
+		ID:          j.ID,
+		Kind:        j.Request.Kind,
+		AppID:       j.Request.AppID,
+		TargetID:    j.Request.ID,
+		ManifestID:  j.Request.ManifestID,
+		Platform:    j.Request.Platform,
+		Beta:        j.Request.Beta,
+		State:       j.state,
+		FileName:    j.result.FileName,
+		InstallPath: j.result.InstallPath,
+		CreatedAt:   j.createdAt,
+		UpdatedAt:   j.updatedAt,
+	}
+	if j.err != nil {
+		status.Error = j.err.Error()
+		var coded interface{ Code() string }
+		if errors.As(j.err, &coded) {
+			status.ErrorCode = coded.Code()
+		}
+	}
+	return status
+}
+
+// Done is closed once the job has reached StateDone or StateFailed.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *Job) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *Job) finish(result Result, err error) {
+	j.mu.Lock()
+	j.result = result
+	j.err = err
+	j.state = StateDone
+	ev := Event{Type: EventState, State: StateDone}
+	if err != nil {
+		j.state = StateFailed
+		ev = Event{Type: EventState, State: StateFailed, Error: err.Error()}
+	}
+	j.updatedAt = time.Now()
+	j.publishLocked(ev)
+
+	close(j.done)
+	for ch := range j.subscribers {
+		close(ch)
+	}
+	j.subscribers = nil
+	j.mu.Unlock()
+}
+
+type Manager struct {
+	runner    Runner
+	queue     chan *Job
+	retention time.Duration
+	timeout   time.Duration
+
+	mu     sync.RWMutex
+	jobs   map[string]*Job
+	active map[string]*Job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type Config struct {
+	Workers   int
+	QueueSize int
+	// Retention is how long finished jobs stay available for polling.
+	Retention time.Duration
+	// Timeout bounds the run time of a single job. Zero means no limit.
+	Timeout time.Duration
+}
+
+func NewManager(cfg Config, runner Runner) *Manager {
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		runner:    runner,
+		queue:     make(chan *Job, cfg.QueueSize),
+		retention: cfg.Retention,
+		timeout:   cfg.Timeout,
+		jobs:      make(map[string]*Job),
+		active:    make(map[string]*Job),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+
+	return m
+}
+
+// Submit enqueues req, or returns the already queued or running job for an
+// identical request so concurrent callers share one download.
+func (m *Manager) Submit(req Request) (*Job, error) {
+	return m.submit(req, true)
+}
+
+// SubmitAndWait submits req and blocks until the job finishes or ctx is done.
+// A job that only synchronous callers are waiting on is cancelled once the
+// last of them gives up.
+func (m *Manager) SubmitAndWait(ctx context.Context, req Request) (*Job, error) {
+	job, err := m.submit(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	job.mu.Lock()
+	job.waiters++
+	job.mu.Unlock()
+
+	err = job.Wait(ctx)
+
+	job.mu.Lock()
+	job.waiters--
+	abandoned := err != nil && job.waiters == 0 && !job.detached
+	job.mu.Unlock()
+
+	if abandoned {
+		m.cancelJob(job)
+	}
+
+	return job, err
+}
+
+func (m *Manager) Cancel(id string) bool {
+	job, ok := m.Get(id)
+	if !ok {
+		return false
+	}
+
+	select {
+	case <-job.done:
+		return false
+	default:
+	}
+
+	m.cancelJob(job)
+	return true
+}
+
+// cancelJob leaves the job active until its runner returns, so a
+// resubmission waits for it instead of running alongside it.
+func (m *Manager) cancelJob(job *Job) {
+	job.mu.Lock()
+	job.canceled = true
+	cancel := job.cancel
+	job.mu.Unlock()
+
+	if cancel != nil {
+		cancel(ErrCanceled)
+	}
+}
+
+func (m *Manager) submit(req Request, detached bool) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ctx.Err() != nil {
+		return nil, ErrClosed
+	}
+
+	m.prune()
+
+	var after <-chan struct{}
+	if job, ok := m.active[req.key()]; ok {
+		job.mu.Lock()
+		canceled := job.canceled
+		if detached && !canceled {
+			job.detached = true
+		}
+		job.mu.Unlock()
+
+		if !canceled {
+			return job, nil
+		}
+		after = job.done
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        newID(),
+		Request:   req,
+		state:     StateQueued,
+		createdAt: now,
+		updatedAt: now,
+		done:      make(chan struct{}),
+		after:     after,
+		detached:  detached,
+
+		subscribers: make(map[chan Event]struct{}),
+	}
+	job.events = append(job.events, Event{Type: EventState, State: StateQueued, Time: now})
+
+	select {
+	case m.queue <- job:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	m.jobs[job.ID] = job
+	m.active[req.key()] = job
+
+	return job, nil
+}
+
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// Close stops the workers and fails every job that is still queued with
+// ErrCanceled, so nothing keeps waiting for it.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.cancel()
+	m.mu.Unlock()
+	m.wg.Wait()
+
+	for {
+		select {
+		case job := <-m.queue:
+			m.mu.Lock()
+			if m.active[job.Request.key()] == job {
+				delete(m.active, job.Request.key())
+			}
+			m.mu.Unlock()
+			job.finish(Result{}, ErrCanceled)
+		default:
+			return
+		}
+	}
+}
+
+func (m *Manager) worker() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case job := <-m.queue:
+			m.run(job)
+		}
+	}
+}
+
+func (m *Manager) run(job *Job) {
+	ctx, cancel := context.WithCancelCause(m.ctx)
+	defer cancel(nil)
+
+	if m.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, m.timeout, ErrJobTimeout)
+		defer cancelTimeout()
+	}
+
+	job.mu.Lock()
+	job.cancel = cancel
+	job.mu.Unlock()
+
+	if job.after != nil {
+		select {
+		case <-job.after:
+		case <-ctx.Done():
+		}
+	}
+
+	// Jobs dequeued while the manager closes are not started.
+	job.mu.RLock()
+	canceled := job.canceled || m.ctx.Err() != nil
+	job.mu.RUnlock()
+
+	var (
+		result Result
+		err    error
+	)
+	if canceled {
+		err = ErrCanceled
+	} else if ctx.Err() != nil {
+		err = context.Cause(ctx)
+	} else {
+		job.SetState(StateDownloading)
+		result, err = m.runner(ctx, job)
+		if err == nil && ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+	}
+
+	m.mu.Lock()
+	if m.active[job.Request.key()] == job {
+		delete(m.active, job.Request.key())
+	}
+	m.mu.Unlock()
+
+	job.finish(result, err)
+}
+
+func (m *Manager) prune() {
+	if m.retention <= 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-m.retention)
+	for id, job := range m.jobs {
+		select {
+		case <-job.done:
+		default:
+			continue
+		}
+
+		job.mu.RLock()
+		expired := job.updatedAt.Before(cutoff)
+		job.mu.RUnlock()
+
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,337 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingRunner runs jobs until they are released or their context ends.
+type blockingRunner struct {
+	started chan *Job
+	release chan struct{}
+}
+
+func newBlockingRunner() *blockingRunner {
+	return &blockingRunner{started: make(chan *Job, 16), release: make(chan struct{})}
+}
+
+func (r *blockingRunner) run(ctx context.Context, job *Job) (Result, error) {
+	r.started <- job
+	select {
+	case <-r.release:
+		return Result{FileName: job.ID}, nil
+	case <-ctx.Done():
+		return Result{}, context.Cause(ctx)
+	}
+}
+
+func (r *blockingRunner) waitStarted(t *testing.T) *Job {
+	t.Helper()
+	select {
+	case job := <-r.started:
+		return job
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job started")
+		return nil
+	}
+}
+
+func waitDone(t *testing.T, job *Job) (Result, error) {
+	t.Helper()
+	select {
+	case <-job.Done():
+		return job.Result()
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %s did not finish", job.ID)
+		return Result{}, nil
+	}
+}
+
+func workshop(id int) Request {
+	return Request{Kind: KindWorkshop, AppID: 4000, ID: id}
+}
+
+func TestRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr bool
+	}{
+		{"workshop", workshop(1), false},
+		{"missing id", Request{Kind: KindWorkshop, AppID: 4000}, true},
+		{"missing app", Request{Kind: KindDepot, ID: 4001}, true},
+		{"pinned depot", Request{Kind: KindDepot, AppID: 4000, ID: 4001, ManifestID: 1}, false},
+		{"pinned collection", Request{Kind: KindCollection, AppID: 4000, ID: 1, ManifestID: 1}, true},
+		{"app", Request{Kind: KindApp, AppID: 4020, Beta: "x86-64", BetaPassword: "s3cr.t_"}, false},
+		{"beta with newline", Request{Kind: KindApp, AppID: 4020, Beta: "x\nforce_install_dir /etc"}, true},
+		{"beta with space", Request{Kind: KindApp, AppID: 4020, Beta: "a b"}, true},
+		{"password with quote", Request{Kind: KindApp, AppID: 4020, BetaPassword: `a"b`}, true},
+		{"unknown kind", Request{Kind: "mod", AppID: 4000, ID: 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubmitDeduplicates(t *testing.T) {
+	runner := newBlockingRunner()
+	m := NewManager(Config{Workers: 2, QueueSize: 8}, runner.run)
+	defer m.Close()
+
+	first, err := m.Submit(workshop(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.waitStarted(t)
+
+	tests := []struct {
+		name string
+		req  Request
+		same bool
+	}{
+		{"identical", workshop(1), true},
+		{"other item", workshop(2), false},
+		{"other platform", Request{Kind: KindWorkshop, AppID: 4000, ID: 1, Platform: "windows"}, false},
+		{"pinned manifest", Request{Kind: KindWorkshop, AppID: 4000, ID: 1, ManifestID: 7}, false},
+		{"without dependencies", Request{Kind: KindWorkshop, AppID: 4000, ID: 1, SkipDependencies: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := m.Submit(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (job == first) != tt.same {
+				t.Errorf("shares the first job: %t, want %t", job == first, tt.same)
+			}
+		})
+	}
+
+	close(runner.release)
+	if _, err := waitDone(t, first); err != nil {
+		t.Fatalf("job failed: %v", err)
+	}
+
+	again, err := m.Submit(workshop(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == first {
+		t.Error("a finished job was reused for a new submission")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	runner := newBlockingRunner()
+	m := NewManager(Config{Workers: 1, QueueSize: 8}, runner.run)
+	defer m.Close()
+
+	running, _ := m.Submit(workshop(1))
+	runner.waitStarted(t)
+	queued, _ := m.Submit(workshop(2))
+
+	// A cancelled job that is still queued finishes once a worker is free.
+	for _, job := range []*Job{running, queued} {
+		if !m.Cancel(job.ID) {
+			t.Fatalf("Cancel(%s) = false", job.ID)
+		}
+		if _, err := waitDone(t, job); !errors.Is(err, ErrCanceled) {
+			t.Errorf("err = %v, want %v", err, ErrCanceled)
+		}
+		if job.State() != StateFailed {
+			t.Errorf("state = %s, want %s", job.State(), StateFailed)
+		}
+	}
+
+	if m.Cancel(running.ID) {
+		t.Error("cancelling a finished job succeeded")
+	}
+	if m.Cancel("unknown") {
+		t.Error("cancelling an unknown job succeeded")
+	}
+}
+
+func TestResubmitWaitsForCancelledJob(t *testing.T) {
+	// The runner ignores cancellation, like a download that takes a while
+	// to stop.
+	started := make(chan *Job, 2)
+	release := make(chan struct{})
+	m := NewManager(Config{Workers: 2, QueueSize: 8}, func(ctx context.Context, job *Job) (Result, error) {
+		started <- job
+		<-release
+		return Result{}, nil
+	})
+	defer m.Close()
+
+	first, _ := m.Submit(workshop(1))
+	<-started
+	m.Cancel(first.ID)
+
+	second, err := m.Submit(workshop(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Fatal("a cancelled job was reused for a new submission")
+	}
+	if again, _ := m.Submit(workshop(1)); again != second {
+		t.Error("the replacement job was not shared")
+	}
+
+	select {
+	case <-started:
+		t.Fatal("the replacement started while the cancelled job was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if _, err := waitDone(t, first); !errors.Is(err, ErrCanceled) {
+		t.Errorf("cancelled job err = %v, want %v", err, ErrCanceled)
+	}
+	if _, err := waitDone(t, second); err != nil {
+		t.Errorf("replacement job err = %v", err)
+	}
+}
+
+func TestSubmitAndWaitAbandoned(t *testing.T) {
+	runner := newBlockingRunner()
+	m := NewManager(Config{Workers: 2, QueueSize: 8}, runner.run)
+	defer m.Close()
+
+	tests := []struct {
+		name string
+		// detached jobs are also submitted asynchronously and must outlive
+		// their synchronous waiter.
+		detached bool
+	}{
+		{"synchronous only", false},
+		{"also submitted asynchronously", true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := workshop(100 + i)
+			if tt.detached {
+				if _, err := m.Submit(req); err != nil {
+					t.Fatal(err)
+				}
+				runner.waitStarted(t)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				if !tt.detached {
+					<-runner.started
+				}
+				cancel()
+			}()
+			job, err := m.SubmitAndWait(ctx, req)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("SubmitAndWait err = %v, want %v", err, context.Canceled)
+			}
+
+			if !tt.detached {
+				if _, err := waitDone(t, job); !errors.Is(err, ErrCanceled) {
+					t.Errorf("job err = %v, want %v", err, ErrCanceled)
+				}
+				return
+			}
+			select {
+			case <-job.Done():
+				t.Error("detached job was cancelled with its synchronous waiter")
+			case <-time.After(50 * time.Millisecond):
+			}
+			m.Cancel(job.ID)
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	runner := newBlockingRunner()
+	m := NewManager(Config{Workers: 1, QueueSize: 1, Timeout: 10 * time.Millisecond}, runner.run)
+	defer m.Close()
+
+	job, _ := m.Submit(workshop(1))
+	if _, err := waitDone(t, job); !errors.Is(err, ErrJobTimeout) {
+		t.Errorf("err = %v, want %v", err, ErrJobTimeout)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	runner := newBlockingRunner()
+	m := NewManager(Config{Workers: 1, QueueSize: 1}, runner.run)
+	defer m.Close()
+
+	m.Submit(workshop(1))
+	runner.waitStarted(t)
+	if _, err := m.Submit(workshop(2)); err != nil {
+		t.Fatalf("queueing a job: %v", err)
+	}
+	if _, err := m.Submit(workshop(3)); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("err = %v, want %v", err, ErrQueueFull)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	runner := newBlockingRunner()
+	close(runner.release)
+	m := NewManager(Config{Workers: 1, QueueSize: 4, Retention: 20 * time.Millisecond}, runner.run)
+	defer m.Close()
+
+	job, _ := m.Submit(workshop(1))
+	waitDone(t, job)
+	if _, ok := m.Get(job.ID); !ok {
+		t.Fatal("finished job is gone before its retention")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// Finished jobs are pruned on the next submission.
+	m.Submit(workshop(2))
+	if _, ok := m.Get(job.ID); ok {
+		t.Error("finished job is still available after its retention")
+	}
+}
+
+func TestCloseFinishesQueuedJobs(t *testing.T) {
+	runner := newBlockingRunner()
+	m := NewManager(Config{Workers: 1, QueueSize: 8}, runner.run)
+
+	running, _ := m.Submit(workshop(1))
+	runner.waitStarted(t)
+	queued, _ := m.Submit(workshop(2))
+	_, events, _ := queued.Subscribe()
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := m.SubmitAndWait(context.Background(), workshop(2))
+		waited <- err
+	}()
+
+	m.Close()
+
+	if _, err := waitDone(t, running); !errors.Is(err, context.Canceled) {
+		t.Errorf("running job err = %v, want %v", err, context.Canceled)
+	}
+	if _, err := waitDone(t, queued); !errors.Is(err, ErrCanceled) {
+		t.Errorf("queued job err = %v, want %v", err, ErrCanceled)
+	}
+	for range events {
+	}
+	select {
+	case err := <-waited:
+		if err != nil && !errors.Is(err, ErrClosed) {
+			t.Errorf("SubmitAndWait err = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SubmitAndWait still blocks after Close")
+	}
+
+	if _, err := m.Submit(workshop(3)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Close err = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,93 @@
+// Package retry holds the backoff policy shared by every downloader.
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter randomises each delay by up to this fraction in either direction.
+	Jitter float64
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based).
+// Without a MaxDelay the delay saturates at the largest time.Duration.
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay > 0; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		jittered := float64(delay) * (1 + (rand.Float64()*2-1)*p.Jitter)
+		if jittered >= math.MaxInt64 {
+			delay = math.MaxInt64
+		} else {
+			delay = time.Duration(jittered)
+		}
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay
+}
+
+// Do calls attempt until it succeeds, fails with an error transient rejects
+// or p.MaxAttempts is used up. onRetry, when set, is told about every failure
+// before waiting for the next attempt. Do returns the number of attempts made
+// and the last error, or the context's cause if it ended while waiting.
+func (p Policy) Do(ctx context.Context, transient func(error) bool, onRetry func(attempt int, err error, delay time.Duration), attempt func() error) (int, error) {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || n >= p.MaxAttempts || !transient(err) {
+			return n, err
+		}
+
+		delay := p.Delay(n)
+		if onRetry != nil {
+			onRetry(n, err, delay)
+		}
+		if err := Sleep(ctx, delay); err != nil {
+			return n, err
+		}
+	}
+}
+
+// Sleep waits for d, returning the context's cause if it ends first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,123 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		want    time.Duration
+	}{
+		{"first attempt", Policy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1, time.Second},
+		{"doubles", Policy{BaseDelay: time.Second, MaxDelay: time.Minute}, 3, 4 * time.Second},
+		{"capped", Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 4, 5 * time.Second},
+		{"cap below base", Policy{BaseDelay: time.Second, MaxDelay: time.Millisecond}, 1, time.Millisecond},
+		{"no cap", Policy{BaseDelay: time.Second}, 5, 16 * time.Second},
+		{"no cap first attempt", Policy{BaseDelay: time.Second}, 1, time.Second},
+		{"zero base", Policy{MaxDelay: time.Minute}, 3, 0},
+		{"far beyond the cap", Policy{BaseDelay: time.Second, MaxDelay: time.Minute}, 100, time.Minute},
+		{"no cap saturates", Policy{BaseDelay: time.Second}, 100, math.MaxInt64},
+		{"no cap at the last doubling", Policy{BaseDelay: time.Second}, 34, time.Second << 33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if got := p.Delay(2); got < 1600*time.Millisecond || got > 2400*time.Millisecond {
+			t.Fatalf("Delay(2) = %s, want within 20%% of 2s", got)
+		}
+	}
+
+	full := Policy{BaseDelay: time.Second, Jitter: 5}
+	for i := 0; i < 100; i++ {
+		if got := full.Delay(1); got < 0 {
+			t.Fatalf("Delay(1) = %s, want it never to be negative", got)
+		}
+	}
+
+	// Jitter must not overflow a saturated delay.
+	uncapped := Policy{BaseDelay: time.Second, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if got := uncapped.Delay(100); got < uncapped.Delay(1) {
+			t.Fatalf("Delay(100) = %s, want it to stay saturated", got)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+	transient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		name         string
+		maxAttempts  int
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{"succeeds at once", 3, []error{nil}, 1, nil},
+		{"succeeds after retries", 3, []error{errTransient, errTransient, nil}, 3, nil},
+		{"exhausted", 3, []error{errTransient, errTransient, errTransient}, 3, errTransient},
+		{"permanent", 3, []error{errTransient, errPermanent}, 2, errPermanent},
+		{"single attempt", 1, []error{errTransient}, 1, errTransient},
+		{"zero attempts still runs once", 0, []error{errTransient}, 1, errTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Policy{MaxAttempts: tt.maxAttempts, BaseDelay: time.Millisecond}
+
+			calls, retries := 0, 0
+			n, err := p.Do(context.Background(), transient, func(attempt int, err error, delay time.Duration) {
+				retries++
+				if attempt != calls || !errors.Is(err, tt.errs[calls-1]) {
+					t.Errorf("onRetry(%d, %v) after call %d", attempt, err, calls)
+				}
+			}, func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+
+			if n != tt.wantAttempts || calls != tt.wantAttempts {
+				t.Errorf("attempts = %d (%d calls), want %d", n, calls, tt.wantAttempts)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if retries != tt.wantAttempts-1 {
+				t.Errorf("onRetry called %d times, want %d", retries, tt.wantAttempts-1)
+			}
+		})
+	}
+}
+
+func TestDoCanceledWhileWaiting(t *testing.T) {
+	errStop := errors.New("stop")
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	p := Policy{MaxAttempts: 5, BaseDelay: time.Hour}
+	n, err := p.Do(ctx, func(error) bool { return true }, func(int, error, time.Duration) {
+		cancel(errStop)
+	}, func() error {
+		return errors.New("failed")
+	})
+
+	if n != 1 || !errors.Is(err, errStop) {
+		t.Errorf("Do = %d, %v, want 1, %v", n, err, errStop)
+	}
+}
@@ -0,0 +1,281 @@
+package steam
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type CacheConfig struct {
+	// Size is the maximum number of cached items and collections together.
+	Size          int
+	ItemTTL       time.Duration
+	CollectionTTL time.Duration
+	// NegativeTTL is how long items and collections Steam reported as
+	// missing are remembered.
+	NegativeTTL time.Duration
+	// Path persists the cache across restarts when set.
+	Path string
+	// FlushInterval is how often changes are written to Path.
+	FlushInterval time.Duration
+}
+
+// Cache is an LRU cache of workshop metadata. Only answers from the Web API
+// are cached; scraped metadata is incomplete and always fetched again.
+type Cache struct {
+	config CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	dirty   bool
+
+	stop      chan struct{}
+	flushDone chan struct{}
+	closeOnce sync.Once
+}
+
+type cacheEntry struct {
+	Key        string        `json:"key"`
+	Item       *WorkshopItem `json:"item,omitempty"`
+	Collection *Collection   `json:"collection,omitempty"`
+	// Missing entries record that Steam does not know the ID.
+	Missing bool      `json:"missing,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+func NewCache(config CacheConfig) (*Cache, error) {
+	c := &Cache{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+
+	if config.Path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(config.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read metadata cache: %w", err)
+	}
+	if err == nil {
+		var entries []*cacheEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata cache: %w", err)
+		}
+		// Entries are stored most recently used first.
+		now := time.Now()
+		for i := min(len(entries), config.Size) - 1; i >= 0; i-- {
+			if entries[i].Expires.After(now) {
+				c.add(entries[i])
+			}
+		}
+	}
+
+	if config.FlushInterval > 0 {
+		c.stop = make(chan struct{})
+		c.flushDone = make(chan struct{})
+		go c.flushLoop()
+	}
+	return c, nil
+}
+
+// Close stops the periodic flush and writes the cache to disk a last time.
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	c.closeOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+			<-c.flushDone
+		}
+	})
+	return c.Save()
+}
+
+// The lookups below treat a nil *Cache as an empty cache that stores nothing.
+
+func (c *Cache) item(id int) (*WorkshopItem, bool) {
+	if c == nil {
+		return nil, false
+	}
+	entry, ok := c.get(itemKey(id))
+	if !ok {
+		return nil, false
+	}
+	return entry.Item, true
+}
+
+// putItem caches item, or records id as missing if item is nil.
+func (c *Cache) putItem(id int, item *WorkshopItem) {
+	if c == nil {
+		return
+	}
+	ttl := c.config.ItemTTL
+	if item == nil {
+		ttl = c.config.NegativeTTL
+	}
+	c.put(&cacheEntry{Key: itemKey(id), Item: item, Missing: item == nil}, ttl)
+}
+
+func (c *Cache) collection(id int) (*Collection, bool) {
+	if c == nil {
+		return nil, false
+	}
+	entry, ok := c.get(collectionKey(id))
+	if !ok {
+		return nil, false
+	}
+	return entry.Collection, true
+}
+
+func (c *Cache) putCollection(id int, collection *Collection) {
+	if c == nil {
+		return
+	}
+	ttl := c.config.CollectionTTL
+	if collection == nil {
+		ttl = c.config.NegativeTTL
+	}
+	c.put(&cacheEntry{Key: collectionKey(id), Collection: collection, Missing: collection == nil}, ttl)
+}
+
+// Invalidate drops everything cached about id.
+func (c *Cache) Invalidate(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range []string{itemKey(id), collectionKey(id)} {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.dirty = true
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.Expires.After(time.Now()) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *Cache) put(entry *cacheEntry, ttl time.Duration) {
+	if ttl <= 0 || c.config.Size <= 0 {
+		return
+	}
+	entry.Expires = time.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.Key]; ok {
+		c.remove(elem)
+	}
+	c.add(entry)
+	for c.order.Len() > c.config.Size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache) add(entry *cacheEntry) {
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	c.dirty = true
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).Key)
+	c.order.Remove(elem)
+	c.dirty = true
+}
+
+// Save writes the cache to its Path, if it has one and anything changed.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	if c.config.Path == "" || !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	entries := make([]*cacheEntry, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, elem.Value.(*cacheEntry))
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	// Write a copy first so a crash never leaves a truncated cache behind.
+	tmp := c.config.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.config.Path)
+}
+
+func (c *Cache) flushLoop() {
+	defer close(c.flushDone)
+
+	ticker := time.NewTicker(c.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.Save(); err != nil {
+				log.Printf("⚠️ Failed to persist metadata cache: %v", err)
+			}
+		}
+	}
+}
+
+func itemKey(id int) string       { return fmt.Sprintf("item:%d", id) }
+func collectionKey(id int) string { return fmt.Sprintf("collection:%d", id) }
+
+type refreshKey struct{}
+
+// WithRefresh makes lookups with the returned context bypass the cache. The
+// fresh answers still replace what was cached.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+func refresh(ctx context.Context) bool {
+	v, _ := ctx.Value(refreshKey{}).(bool)
+	return v
+}
@@ -0,0 +1,128 @@
+package steam
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, config CacheConfig) *Cache {
+	t.Helper()
+	c, err := NewCache(config)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	return c
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := newTestCache(t, CacheConfig{
+		Size:          10,
+		ItemTTL:       time.Hour,
+		CollectionTTL: 20 * time.Millisecond,
+		NegativeTTL:   time.Hour,
+	})
+
+	c.putItem(1, &WorkshopItem{ID: 1, Title: "one"})
+	c.putItem(2, nil)
+	c.putCollection(3, &Collection{ID: 3})
+
+	tests := []struct {
+		name    string
+		lookup  func() (bool, bool)
+		cached  bool
+		missing bool
+	}{
+		{"item", func() (bool, bool) { item, ok := c.item(1); return ok, item == nil }, true, false},
+		{"missing item", func() (bool, bool) { item, ok := c.item(2); return ok, item == nil }, true, true},
+		{"unknown item", func() (bool, bool) { item, ok := c.item(4); return ok, item == nil }, false, true},
+		{"collection", func() (bool, bool) { col, ok := c.collection(3); return ok, col == nil }, true, false},
+	}
+	for _, tt := range tests {
+		cached, missing := tt.lookup()
+		if cached != tt.cached || missing != tt.missing {
+			t.Errorf("%s: cached %t, missing %t, want %t, %t", tt.name, cached, missing, tt.cached, tt.missing)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.collection(3); ok {
+		t.Error("collection is still cached after its TTL")
+	}
+	if _, ok := c.item(1); !ok {
+		t.Error("item expired before its TTL")
+	}
+}
+
+func TestCacheDisabledTTL(t *testing.T) {
+	c := newTestCache(t, CacheConfig{Size: 10, ItemTTL: time.Hour})
+
+	c.putItem(1, nil)
+	if _, ok := c.item(1); ok {
+		t.Error("missing item cached without a negative TTL")
+	}
+}
+
+func TestCacheLRU(t *testing.T) {
+	c := newTestCache(t, CacheConfig{Size: 2, ItemTTL: time.Hour})
+
+	c.putItem(1, &WorkshopItem{ID: 1})
+	c.putItem(2, &WorkshopItem{ID: 2})
+	c.item(1) // 2 is now the least recently used
+	c.putItem(3, &WorkshopItem{ID: 3})
+
+	for id, want := range map[int]bool{1: true, 2: false, 3: true} {
+		if _, ok := c.item(id); ok != want {
+			t.Errorf("item %d cached: %t, want %t", id, ok, want)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+
+	c.Invalidate(1)
+	if _, ok := c.item(1); ok {
+		t.Error("invalidated item is still cached")
+	}
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Len() after Clear = %d, want 0", c.Len())
+	}
+}
+
+func TestCacheNil(t *testing.T) {
+	var c *Cache
+	c.putItem(1, &WorkshopItem{ID: 1})
+	if _, ok := c.item(1); ok {
+		t.Error("nil cache returned an item")
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close on a nil cache: %v", err)
+	}
+}
+
+func TestCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	config := CacheConfig{Size: 2, ItemTTL: time.Hour, Path: path, FlushInterval: time.Hour}
+
+	c := newTestCache(t, config)
+	c.putItem(1, &WorkshopItem{ID: 1, Title: "one"})
+	c.putItem(2, &WorkshopItem{ID: 2, Title: "two"})
+	c.item(1)
+	// Close must write what the hourly flush has not yet.
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	restored := newTestCache(t, config)
+	defer restored.Close()
+	if item, ok := restored.item(2); !ok || item.Title != "two" {
+		t.Errorf("restored item 2 = %+v, %t", item, ok)
+	}
+
+	// The recency order survives, so item 2 (just used) outlives item 1.
+	restored.putItem(3, &WorkshopItem{ID: 3})
+	if _, ok := restored.item(1); ok {
+		t.Error("least recently used item survived the restore")
+	}
+}
@@ -0,0 +1,178 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIBaseURL       = "https://api.steampowered.com"
+	DefaultCommunityBaseURL = "https://steamcommunity.com"
+)
+
+var ErrNotFound = errors.New("steam: published file not found")
+
+// Client looks up workshop metadata through the Steam Web API and falls back
+// to scraping the community pages when the API is unavailable. The base URLs
+// can point at a local stand-in for testing.
+type Client struct {
+	APIBaseURL       string
+	CommunityBaseURL string
+	HTTPClient       *http.Client
+	// APIKey enables the Web API methods that require a key, such as
+	// search. The others work without one.
+	APIKey string
+	// Cache, if set, keeps item and collection metadata between lookups.
+	Cache *Cache
+}
+
+func NewClient() *Client {
+	return &Client{
+		APIBaseURL:       DefaultAPIBaseURL,
+		CommunityBaseURL: DefaultCommunityBaseURL,
+		HTTPClient:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Client) GetWorkshopName(ctx context.Context, workshopID int) (string, error) {
+	item, err := c.GetWorkshopItem(ctx, workshopID)
+	if err != nil {
+		return "", err
+	}
+	return item.Title, nil
+}
+
+// GetCollection resolves a collection and the collections it links. The
+// scraper fallback only sees the top level and cannot tell the items' apps.
+func (c *Client) GetCollection(ctx context.Context, collectionID int) (*Collection, error) {
+	if !refresh(ctx) {
+		if collection, ok := c.Cache.collection(collectionID); ok {
+			if collection == nil {
+				return nil, fmt.Errorf("%w: collection %d", ErrNotFound, collectionID)
+			}
+			return collection, nil
+		}
+	}
+
+	collection, err := c.collection(ctx, collectionID)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		c.Cache.putCollection(collectionID, collection)
+		return collection, err
+	}
+
+	log.Printf("⚠️ Steam Web API lookup of collection %d failed, falling back to scraping: %v", collectionID, err)
+	title, items, scrapeErr := c.scrapeCollectionItems(ctx, collectionID)
+	if scrapeErr != nil {
+		return nil, errors.Join(err, scrapeErr)
+	}
+	return &Collection{ID: collectionID, Title: title, Items: items}, nil
+}
+
+// GetWorkshopItem returns the full metadata of one item, including the items
+// it requires. Only the title, description, preview, tags and dependencies
+// survive the scraper fallback.
+func (c *Client) GetWorkshopItem(ctx context.Context, workshopID int) (*WorkshopItem, error) {
+	items, err := c.GetWorkshopItems(ctx, []int{workshopID})
+	if err != nil {
+		return nil, err
+	}
+	if items[0] == nil {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, workshopID)
+	}
+	return items[0], nil
+}
+
+// GetWorkshopItems is the batched form of GetWorkshopItem. Items Steam does
+// not know are returned as nil.
+func (c *Client) GetWorkshopItems(ctx context.Context, ids []int) ([]*WorkshopItem, error) {
+	items := make([]*WorkshopItem, len(ids))
+
+	// Only look up what is not cached; uncached holds indexes into ids.
+	var uncached, lookup []int
+	for i, id := range ids {
+		if !refresh(ctx) {
+			if item, ok := c.Cache.item(id); ok {
+				items[i] = item
+				continue
+			}
+		}
+		uncached = append(uncached, i)
+		lookup = append(lookup, id)
+	}
+	if len(lookup) == 0 {
+		return items, nil
+	}
+
+	found, err := c.workshopItems(ctx, lookup)
+	if err == nil {
+		for j, i := range uncached {
+			items[i] = found[j]
+			c.Cache.putItem(lookup[j], found[j])
+		}
+		return items, nil
+	}
+
+	log.Printf("⚠️ Steam Web API lookup of %d items failed, falling back to scraping: %v", len(lookup), err)
+	for j, i := range uncached {
+		item, scrapeErr := c.scrapeWorkshopItem(ctx, lookup[j])
+		if scrapeErr != nil {
+			return nil, errors.Join(err, scrapeErr)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// workshopItems asks GetCollectionDetails for the items' children as well,
+// since that is where Steam lists required items.
+func (c *Client) workshopItems(ctx context.Context, ids []int) ([]*WorkshopItem, error) {
+	details, err := c.GetPublishedFileDetails(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	children, err := c.GetCollectionDetails(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*WorkshopItem, len(ids))
+	for i := range details {
+		if details[i].Err() != nil {
+			continue
+		}
+		item := details[i].item()
+		if children[i].Err() == nil {
+			for _, child := range children[i].Children {
+				item.Dependencies = append(item.Dependencies, child.ID)
+			}
+		}
+		items[i] = &item
+	}
+	return items, nil
+}
+
+func (c *Client) url(base, path string) string {
+	return strings.TrimRight(base, "/") + path
+}
+
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("steam returned status %d", res.StatusCode)
+	}
+	return res, nil
+}
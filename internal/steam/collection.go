@@ -0,0 +1,102 @@
+package steam
+
+import (
+	"context"
+	"log"
+)
+
+// Collection is a workshop collection with its items and the collections it
+// links, in the order Steam lists them.
+type Collection struct {
+	ID          int
+	AppID       int
+	Title       string
+	Items       []WorkshopItem
+	Collections []*Collection
+}
+
+// collection walks linked collections one level at a time. A collection that
+// is linked more than once, including by one of its own descendants, is only
+// expanded where it is first seen.
+func (c *Client) collection(ctx context.Context, collectionID int) (*Collection, error) {
+	root := &Collection{ID: collectionID}
+	seen := map[int]bool{collectionID: true}
+
+	for level := []*Collection{root}; len(level) > 0; {
+		ids := make([]int, len(level))
+		for i, coll := range level {
+			ids[i] = coll.ID
+		}
+
+		collections, err := c.GetCollectionDetails(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		// Look up the children of the whole level at once, plus the root
+		// itself for its title.
+		var lookup []int
+		if level[0] == root {
+			lookup = append(lookup, collectionID)
+		}
+		for _, details := range collections {
+			for _, child := range details.Children {
+				lookup = append(lookup, child.ID)
+			}
+		}
+
+		details, err := c.GetPublishedFileDetails(ctx, lookup)
+		if err != nil {
+			return nil, err
+		}
+		files := make(map[int]*PublishedFileDetails, len(details))
+		for i := range details {
+			files[details[i].ID] = &details[i]
+		}
+
+		if level[0] == root {
+			if err := files[collectionID].Err(); err != nil {
+				return nil, err
+			}
+			root.Title = files[collectionID].Title
+			root.AppID = files[collectionID].ConsumerAppID
+		}
+
+		var next []*Collection
+		for i, coll := range level {
+			if err := collections[i].Err(); err != nil {
+				if coll == root {
+					return nil, err
+				}
+				log.Printf("⚠️ Skipping linked collection %d: %v", coll.ID, err)
+				continue
+			}
+
+			for _, child := range collections[i].Children {
+				file := files[child.ID]
+				if err := file.Err(); err != nil {
+					log.Printf("⚠️ Skipping item %d of collection %d: %v", child.ID, coll.ID, err)
+					continue
+				}
+
+				if child.FileType != fileTypeCollection {
+					coll.Items = append(coll.Items, file.item())
+					continue
+				}
+
+				if seen[child.ID] {
+					log.Printf("⚠️ Collection %d links collection %d again, skipping it", coll.ID, child.ID)
+					continue
+				}
+				seen[child.ID] = true
+
+				linked := &Collection{ID: child.ID, AppID: file.ConsumerAppID, Title: file.Title}
+				coll.Collections = append(coll.Collections, linked)
+				next = append(next, linked)
+			}
+		}
+		level = next
+	}
+
+	return root, nil
+}
@@ -0,0 +1,98 @@
+package steam
+
+import (
+	"context"
+	"fmt"
+)
+
+// DependencyGraph holds every item reachable from Root through required
+// items.
+type DependencyGraph struct {
+	Root  int
+	Items map[int]*WorkshopItem
+	// Order lists the found items breadth-first, starting with Root.
+	Order []int
+	// Missing lists required items Steam does not know, e.g. because they
+	// were deleted or made private.
+	Missing []int
+}
+
+// ResolveDependencies walks the required items of workshopID one level at a
+// time, so each level costs a single batch of Web API calls. Items already
+// seen are not looked up again, which also breaks cycles.
+func (c *Client) ResolveDependencies(ctx context.Context, workshopID int) (*DependencyGraph, error) {
+	graph := &DependencyGraph{Root: workshopID, Items: make(map[int]*WorkshopItem)}
+
+	seen := map[int]bool{workshopID: true}
+	for level := []int{workshopID}; len(level) > 0; {
+		items, err := c.GetWorkshopItems(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []int
+		for i, item := range items {
+			if item == nil {
+				if level[i] == workshopID {
+					return nil, fmt.Errorf("%w: %d", ErrNotFound, workshopID)
+				}
+				graph.Missing = append(graph.Missing, level[i])
+				continue
+			}
+
+			graph.Items[item.ID] = item
+			graph.Order = append(graph.Order, item.ID)
+			for _, dep := range item.Dependencies {
+				if !seen[dep] {
+					seen[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		level = next
+	}
+
+	return graph, nil
+}
+
+// DependencyNode is one item of the tree returned by DependencyGraph.Tree.
+type DependencyNode struct {
+	ID    int    `json:"id"`
+	Title string `json:"title,omitempty"`
+	// Missing items could not be found on Steam.
+	Missing bool `json:"missing,omitempty"`
+	// Cycle marks an item that already appears further up its branch; its
+	// dependencies are listed there.
+	Cycle        bool              `json:"cycle,omitempty"`
+	Dependencies []*DependencyNode `json:"dependencies,omitempty"`
+}
+
+// Tree expands the graph from Root. Items required by several others appear
+// under each of them.
+func (g *DependencyGraph) Tree() *DependencyNode {
+	return g.node(g.Root, make(map[int]bool))
+}
+
+func (g *DependencyGraph) node(id int, branch map[int]bool) *DependencyNode {
+	node := &DependencyNode{ID: id}
+
+	item, ok := g.Items[id]
+	if !ok {
+		node.Missing = true
+		return node
+	}
+	node.Title = item.Title
+
+	if branch[id] {
+		node.Cycle = true
+		return node
+	}
+
+	branch[id] = true
+	for _, dep := range item.Dependencies {
+		node.Dependencies = append(node.Dependencies, g.node(dep, branch))
+	}
+	delete(branch, id)
+
+	return node
+}
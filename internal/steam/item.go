@@ -0,0 +1,94 @@
+package steam
+
+import (
+	"time"
+)
+
+// Visibility values of a published file.
+const (
+	VisibilityPublic      = "public"
+	VisibilityFriendsOnly = "friends_only"
+	VisibilityPrivate     = "private"
+	VisibilityUnlisted    = "unlisted"
+)
+
+var visibilities = []string{VisibilityPublic, VisibilityFriendsOnly, VisibilityPrivate, VisibilityUnlisted}
+
+// WorkshopItem is the metadata of a workshop item. Zero values mean Steam did
+// not report the field, which is the case for most of them when the item was
+// scraped instead of looked up through the Web API.
+type WorkshopItem struct {
+	ID          int    `json:"id"`
+	AppID       int    `json:"app_id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// Author is the SteamID64 of the creator.
+	Author     string     `json:"author,omitempty"`
+	FileName   string     `json:"file_name,omitempty"`
+	FileSize   int64      `json:"file_size"`
+	FileURL    string     `json:"file_url,omitempty"`
+	PreviewURL string     `json:"preview_url,omitempty"`
+	Tags       []string   `json:"tags"`
+	Created    *time.Time `json:"time_created,omitempty"`
+	Updated    *time.Time `json:"time_updated,omitempty"`
+	Visibility string     `json:"visibility,omitempty"`
+	Banned     bool       `json:"banned"`
+
+	Subscriptions         int `json:"subscriptions"`
+	LifetimeSubscriptions int `json:"lifetime_subscriptions"`
+	Favorited             int `json:"favorited"`
+	Views                 int `json:"views"`
+
+	// Dependencies are the IDs of the items this one requires.
+	Dependencies []int `json:"dependencies"`
+
+	// Rating is only known for search results.
+	Rating *Rating `json:"rating,omitempty"`
+}
+
+// Rating summarizes the votes on an item. The Web API reports the votes and
+// a score between 0 and 1, the browse pages only the stars they display.
+type Rating struct {
+	Score     float64 `json:"score,omitempty"`
+	VotesUp   int     `json:"votes_up,omitempty"`
+	VotesDown int     `json:"votes_down,omitempty"`
+	Stars     int     `json:"stars,omitempty"`
+}
+
+func (d *PublishedFileDetails) item() WorkshopItem {
+	item := WorkshopItem{
+		ID:                    d.ID,
+		AppID:                 d.ConsumerAppID,
+		Title:                 d.Title,
+		Description:           d.Description,
+		Author:                d.Creator,
+		FileName:              d.FileName,
+		FileSize:              int64(d.FileSize),
+		FileURL:               d.FileURL,
+		PreviewURL:            d.PreviewURL,
+		Tags:                  make([]string, 0, len(d.Tags)),
+		Dependencies:          []int{},
+		Banned:                d.Banned != 0,
+		Subscriptions:         d.Subscriptions,
+		LifetimeSubscriptions: d.LifetimeSubscriptions,
+		Favorited:             d.Favorited,
+		Views:                 d.Views,
+	}
+	for _, t := range d.Tags {
+		item.Tags = append(item.Tags, t.Tag)
+	}
+	item.Created = unixTime(d.TimeCreated)
+	item.Updated = unixTime(d.TimeUpdated)
+	if d.Visibility >= 0 && d.Visibility < len(visibilities) {
+		item.Visibility = visibilities[d.Visibility]
+	}
+	return item
+}
+
+func unixTime(sec int64) *time.Time {
+	if sec <= 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0).UTC()
+	return &t
+}
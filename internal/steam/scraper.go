@@ -1,55 +1,62 @@
 package steam
 
 import (
+	"context"
 	"fmt"
-	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-type WorkshopItem struct {
-	ID    int
-	Title string
-}
-
-func GetWorkshopName(workshopID int) (string, error) {
-	url := fmt.Sprintf("https://steamcommunity.com/sharedfiles/filedetails/?id=%d", workshopID)
-	res, err := http.Get(url)
+func (c *Client) scrapeWorkshopItem(ctx context.Context, workshopID int) (*WorkshopItem, error) {
+	res, err := c.get(ctx, c.url(c.CommunityBaseURL, fmt.Sprintf("/sharedfiles/filedetails/?id=%d", workshopID)))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return "", fmt.Errorf("steam returned status %d", res.StatusCode)
-	}
-
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	title := doc.Find("div.workshopItemTitle").First().Text()
+	title := strings.TrimSpace(doc.Find("div.workshopItemTitle").First().Text())
 	if title == "" {
-		return "", fmt.Errorf("could not find title for workshop item %d", workshopID)
+		return nil, fmt.Errorf("%w: no title on the page of workshop item %d", ErrNotFound, workshopID)
+	}
+
+	item := &WorkshopItem{
+		ID:           workshopID,
+		Title:        title,
+		Description:  strings.TrimSpace(doc.Find("div.workshopItemDescription").First().Text()),
+		Tags:         []string{},
+		Dependencies: []int{},
 	}
-	return strings.TrimSpace(title), nil
+	if src, ok := doc.Find("#previewImageMain, #previewImage").First().Attr("src"); ok {
+		item.PreviewURL = src
+	}
+	doc.Find("div.workshopTags a").Each(func(i int, s *goquery.Selection) {
+		item.Tags = append(item.Tags, strings.TrimSpace(s.Text()))
+	})
+	doc.Find("#RequiredItems a").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if id, ok := idFromURL(href); ok {
+			item.Dependencies = append(item.Dependencies, id)
+		}
+	})
+	return item, nil
 }
 
-func GetCollectionItems(collectionID int) (string, []WorkshopItem, error) {
-	url := fmt.Sprintf("https://steamcommunity.com/sharedfiles/filedetails/?id=%d", collectionID)
-	res, err := http.Get(url)
+func (c *Client) scrapeCollectionItems(ctx context.Context, collectionID int) (string, []WorkshopItem, error) {
+	res, err := c.get(ctx, c.url(c.CommunityBaseURL, fmt.Sprintf("/sharedfiles/filedetails/?id=%d", collectionID)))
 	if err != nil {
 		return "", nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return "", nil, fmt.Errorf("steam returned status %d", res.StatusCode)
-	}
-
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
 		return "", nil, err
@@ -60,20 +67,83 @@ func GetCollectionItems(collectionID int) (string, []WorkshopItem, error) {
 
 	doc.Find("div.collectionItem").Each(func(i int, s *goquery.Selection) {
 		title := s.Find("div.collectionItemTitle").Text()
-		idStr, exists := s.Find("a").Attr("href")
-		if !exists {
-			return
-		}
-		idParts := strings.Split(idStr, "=")
-		if len(idParts) != 2 {
+		href, _ := s.Find("a").Attr("href")
+		id, ok := idFromURL(href)
+		if !ok {
 			return
 		}
-		id, err := strconv.Atoi(idParts[1])
+		items = append(items, WorkshopItem{ID: id, Title: strings.TrimSpace(title)})
+	})
+
+	return strings.TrimSpace(collectionTitle), items, nil
+}
+
+// idFromURL extracts the id parameter of a filedetails link.
+func idFromURL(href string) (int, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(u.Query().Get("id"))
+	return id, err == nil
+}
+
+var (
+	starsRegex  = regexp.MustCompile(`(\d)-star`)
+	totalsRegex = regexp.MustCompile(`of ([\d,.]+) entries`)
+)
+
+func (c *Client) scrapeSearch(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	params := url.Values{}
+	params.Set("appid", strconv.Itoa(q.AppID))
+	params.Set("searchtext", q.Text)
+	params.Set("browsesort", q.sort().browseSort)
+	params.Set("actualsort", q.sort().browseSort)
+	params.Set("section", "readytouseitems")
+	params.Set("p", strconv.Itoa(q.Page))
+	params.Set("numperpage", strconv.Itoa(SearchPageSize))
+	for _, tag := range q.Tags {
+		params.Add("requiredtags[]", tag)
+	}
+
+	res, err := c.get(ctx, c.url(c.CommunityBaseURL, "/workshop/browse/?"+params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{Page: q.Page, Items: []WorkshopItem{}}
+	doc.Find("div.workshopItem").Each(func(i int, s *goquery.Selection) {
+		link := s.Find("a.ugc").First()
+		id, err := strconv.Atoi(link.AttrOr("data-publishedfileid", ""))
 		if err != nil {
 			return
 		}
-		items = append(items, WorkshopItem{ID: id, Title: strings.TrimSpace(title)})
+		appID, _ := strconv.Atoi(link.AttrOr("data-appid", ""))
+
+		item := WorkshopItem{
+			ID:         id,
+			AppID:      appID,
+			Title:      strings.TrimSpace(s.Find("div.workshopItemTitle").First().Text()),
+			PreviewURL: s.Find("img.workshopItemPreviewImage").First().AttrOr("src", ""),
+			Tags:       []string{},
+		}
+		if m := starsRegex.FindStringSubmatch(s.Find("img.fileRating").First().AttrOr("src", "")); m != nil {
+			stars, _ := strconv.Atoi(m[1])
+			item.Rating = &Rating{Stars: stars}
+		}
+		result.Items = append(result.Items, item)
 	})
 
-	return strings.TrimSpace(collectionTitle), items, nil
+	if m := totalsRegex.FindStringSubmatch(doc.Find("div.workshopBrowsePagingInfo").First().Text()); m != nil {
+		result.Total, _ = strconv.Atoi(strings.NewReplacer(",", "", ".", "").Replace(m[1]))
+	} else {
+		result.Total = (q.Page-1)*SearchPageSize + len(result.Items)
+	}
+	return result, nil
 }
@@ -0,0 +1,216 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// SearchPageSize is the number of items per search page, matching the
+// largest page the browse pages offer.
+const SearchPageSize = 30
+
+type searchSort struct {
+	queryType  int
+	browseSort string
+}
+
+// searchSorts maps the sort orders of SearchQuery to QueryFiles' query_type
+// and the browse pages' browsesort.
+var searchSorts = map[string]searchSort{
+	"relevance":     {12, "textsearch"},
+	"trend":         {3, "trend"},
+	"top_rated":     {0, "toprated"},
+	"recent":        {1, "mostrecent"},
+	"updated":       {21, "lastupdated"},
+	"subscriptions": {9, "totaluniquesubscribers"},
+}
+
+type SearchQuery struct {
+	AppID int
+	Text  string
+	Tags  []string
+	// Sort is one of relevance, trend, top_rated, recent, updated or
+	// subscriptions. It defaults to relevance when searching for text and
+	// to trend otherwise.
+	Sort string
+	// Page starts at 1.
+	Page int
+}
+
+func (q *SearchQuery) Validate() error {
+	if q.AppID <= 0 {
+		return errors.New("app_id must be a positive integer")
+	}
+	if q.Page < 1 {
+		return errors.New("page must be a positive integer")
+	}
+	if _, ok := searchSorts[q.Sort]; q.Sort != "" && !ok {
+		sorts := make([]string, 0, len(searchSorts))
+		for name := range searchSorts {
+			sorts = append(sorts, name)
+		}
+		slices.Sort(sorts)
+		return fmt.Errorf("unknown sort %q, expected one of %v", q.Sort, sorts)
+	}
+	return nil
+}
+
+func (q *SearchQuery) sort() searchSort {
+	switch {
+	case q.Sort != "":
+		return searchSorts[q.Sort]
+	case q.Text != "":
+		return searchSorts["relevance"]
+	default:
+		return searchSorts["trend"]
+	}
+}
+
+type SearchResult struct {
+	// Total is the number of matching items over all pages.
+	Total int            `json:"total"`
+	Page  int            `json:"page"`
+	Items []WorkshopItem `json:"items"`
+}
+
+// Search lists workshop items through IPublishedFileService/QueryFiles when
+// the client has an API key, and scrapes the workshop browse pages
+// otherwise. Scraped items only carry their ID, title, preview and star
+// rating.
+func (c *Client) Search(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.APIKey != "" {
+		result, err := c.queryFiles(ctx, q)
+		if err == nil {
+			return result, nil
+		}
+		log.Printf("⚠️ Steam Web API search failed, falling back to scraping: %v", err)
+	}
+	return c.scrapeSearch(ctx, q)
+}
+
+// queryFile is an entry of QueryFiles, which names some fields differently
+// from GetPublishedFileDetails.
+type queryFile struct {
+	ID                    int     `json:"publishedfileid,string"`
+	Result                int     `json:"result"`
+	Creator               string  `json:"creator"`
+	ConsumerAppID         int     `json:"consumer_appid"`
+	FileName              string  `json:"filename"`
+	FileSize              flexInt `json:"file_size"`
+	FileURL               string  `json:"file_url"`
+	PreviewURL            string  `json:"preview_url"`
+	Title                 string  `json:"title"`
+	Description           string  `json:"file_description"`
+	TimeCreated           int64   `json:"time_created"`
+	TimeUpdated           int64   `json:"time_updated"`
+	Visibility            int     `json:"visibility"`
+	Banned                bool    `json:"banned"`
+	Subscriptions         int     `json:"subscriptions"`
+	LifetimeSubscriptions int     `json:"lifetime_subscriptions"`
+	Favorited             int     `json:"favorited"`
+	Views                 int     `json:"views"`
+	Tags                  []struct {
+		Tag string `json:"tag"`
+	} `json:"tags"`
+	Children []struct {
+		ID int `json:"publishedfileid,string"`
+	} `json:"children"`
+	VoteData *struct {
+		Score     float64 `json:"score"`
+		VotesUp   int     `json:"votes_up"`
+		VotesDown int     `json:"votes_down"`
+	} `json:"vote_data"`
+}
+
+func (f *queryFile) item() WorkshopItem {
+	item := (&PublishedFileDetails{
+		ID:                    f.ID,
+		Result:                f.Result,
+		Creator:               f.Creator,
+		ConsumerAppID:         f.ConsumerAppID,
+		FileName:              f.FileName,
+		FileSize:              f.FileSize,
+		FileURL:               f.FileURL,
+		PreviewURL:            f.PreviewURL,
+		Title:                 f.Title,
+		Description:           f.Description,
+		TimeCreated:           f.TimeCreated,
+		TimeUpdated:           f.TimeUpdated,
+		Visibility:            f.Visibility,
+		Subscriptions:         f.Subscriptions,
+		LifetimeSubscriptions: f.LifetimeSubscriptions,
+		Favorited:             f.Favorited,
+		Views:                 f.Views,
+		Tags:                  f.Tags,
+	}).item()
+
+	item.Banned = f.Banned
+	for _, child := range f.Children {
+		item.Dependencies = append(item.Dependencies, child.ID)
+	}
+	if v := f.VoteData; v != nil {
+		item.Rating = &Rating{Score: v.Score, VotesUp: v.VotesUp, VotesDown: v.VotesDown}
+	}
+	return item
+}
+
+func (c *Client) queryFiles(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	params := url.Values{}
+	params.Set("key", c.APIKey)
+	params.Set("appid", strconv.Itoa(q.AppID))
+	params.Set("search_text", q.Text)
+	params.Set("query_type", strconv.Itoa(q.sort().queryType))
+	params.Set("page", strconv.Itoa(q.Page))
+	params.Set("numperpage", strconv.Itoa(SearchPageSize))
+	params.Set("return_details", "true")
+	params.Set("return_tags", "true")
+	params.Set("return_children", "true")
+	params.Set("return_vote_data", "true")
+	params.Set("return_short_description", "false")
+	for i, tag := range q.Tags {
+		params.Set(fmt.Sprintf("requiredtags[%d]", i), tag)
+	}
+	if q.sort().browseSort == "trend" {
+		params.Set("days", "7")
+	}
+
+	res, err := c.get(ctx, c.url(c.APIBaseURL, "/IPublishedFileService/QueryFiles/v1/?"+params.Encode()))
+	if err != nil {
+		// The URL in the error would reveal the key.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		Response struct {
+			Total                int         `json:"total"`
+			PublishedFileDetails []queryFile `json:"publishedfiledetails"`
+		} `json:"response"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode steam web api response: %w", err)
+	}
+
+	result := &SearchResult{Total: body.Response.Total, Page: q.Page, Items: []WorkshopItem{}}
+	for _, f := range body.Response.PublishedFileDetails {
+		if f.Result != 0 && f.Result != resultOK {
+			continue
+		}
+		result.Items = append(result.Items, f.item())
+	}
+	return result, nil
+}
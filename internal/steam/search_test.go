@@ -0,0 +1,46 @@
+package steam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearchQueryType(t *testing.T) {
+	// The values of EPublishedFileQueryType that each sort must send.
+	tests := []struct {
+		sort string
+		text string
+		want string
+	}{
+		{"relevance", "map", "12"},
+		{"trend", "", "3"},
+		{"top_rated", "", "0"},
+		{"recent", "", "1"},
+		{"updated", "", "21"},
+		{"subscriptions", "", "9"},
+		{"", "map", "12"},
+		{"", "", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query().Get("query_type")
+				w.Write([]byte(`{"response":{"total":0}}`))
+			}))
+			defer server.Close()
+
+			c := NewClient()
+			c.APIBaseURL = server.URL
+			c.APIKey = "key"
+			if _, err := c.Search(context.Background(), SearchQuery{AppID: 4000, Text: tt.text, Sort: tt.sort, Page: 1}); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("query_type = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
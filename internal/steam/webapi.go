@@ -0,0 +1,192 @@
+package steam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxBatchSize is the number of IDs sent per Web API request.
+const maxBatchSize = 100
+
+// resultOK is the EResult value for success; anything else, typically 9
+// (File Not Found), means the file is missing, hidden or removed.
+const resultOK = 1
+
+type PublishedFileDetails struct {
+	ID            int     `json:"publishedfileid,string"`
+	Result        int     `json:"result"`
+	Creator       string  `json:"creator"`
+	CreatorAppID  int     `json:"creator_app_id"`
+	ConsumerAppID int     `json:"consumer_app_id"`
+	FileName      string  `json:"filename"`
+	FileSize      flexInt `json:"file_size"`
+	FileURL       string  `json:"file_url"`
+	PreviewURL    string  `json:"preview_url"`
+	Title         string  `json:"title"`
+	Description   string  `json:"description"`
+	TimeCreated   int64   `json:"time_created"`
+	TimeUpdated   int64   `json:"time_updated"`
+	Visibility    int     `json:"visibility"`
+	Banned        int     `json:"banned"`
+	Subscriptions int     `json:"subscriptions"`
+	Favorited     int     `json:"favorited"`
+	Views         int     `json:"views"`
+	// Lifetime counts include users who have since unsubscribed.
+	LifetimeSubscriptions int `json:"lifetime_subscriptions"`
+	LifetimeFavorited     int `json:"lifetime_favorited"`
+	Tags                  []struct {
+		Tag string `json:"tag"`
+	} `json:"tags"`
+}
+
+func (d *PublishedFileDetails) Err() error {
+	if d.Result != resultOK {
+		return fmt.Errorf("%w: %d (result %d)", ErrNotFound, d.ID, d.Result)
+	}
+	return nil
+}
+
+type CollectionChild struct {
+	ID        int `json:"publishedfileid,string"`
+	SortOrder int `json:"sortorder"`
+	// FileType is 0 for workshop items and 2 for nested collections.
+	FileType int `json:"filetype"`
+}
+
+// fileTypeCollection is the CollectionChild.FileType of linked collections.
+const fileTypeCollection = 2
+
+type CollectionDetails struct {
+	ID       int               `json:"publishedfileid,string"`
+	Result   int               `json:"result"`
+	Children []CollectionChild `json:"children"`
+}
+
+func (d *CollectionDetails) Err() error {
+	if d.Result != resultOK {
+		return fmt.Errorf("%w: collection %d (result %d)", ErrNotFound, d.ID, d.Result)
+	}
+	return nil
+}
+
+// GetPublishedFileDetails calls ISteamRemoteStorage/GetPublishedFileDetails,
+// batching large requests. Details are returned in the order of ids; check
+// each one's Err for files Steam could not return.
+func (c *Client) GetPublishedFileDetails(ctx context.Context, ids []int) ([]PublishedFileDetails, error) {
+	var details []PublishedFileDetails
+	for start := 0; start < len(ids); start += maxBatchSize {
+		batch := ids[start:min(start+maxBatchSize, len(ids))]
+
+		var res struct {
+			Response struct {
+				PublishedFileDetails []PublishedFileDetails `json:"publishedfiledetails"`
+			} `json:"response"`
+		}
+		if err := c.postIDs(ctx, "/ISteamRemoteStorage/GetPublishedFileDetails/v1/", "itemcount", batch, &res); err != nil {
+			return nil, err
+		}
+
+		found, err := inOrder(batch, res.Response.PublishedFileDetails, func(d PublishedFileDetails) int { return d.ID })
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, found...)
+	}
+	return details, nil
+}
+
+// GetCollectionDetails calls ISteamRemoteStorage/GetCollectionDetails in the
+// same way as GetPublishedFileDetails.
+func (c *Client) GetCollectionDetails(ctx context.Context, ids []int) ([]CollectionDetails, error) {
+	var details []CollectionDetails
+	for start := 0; start < len(ids); start += maxBatchSize {
+		batch := ids[start:min(start+maxBatchSize, len(ids))]
+
+		var res struct {
+			Response struct {
+				CollectionDetails []CollectionDetails `json:"collectiondetails"`
+			} `json:"response"`
+		}
+		if err := c.postIDs(ctx, "/ISteamRemoteStorage/GetCollectionDetails/v1/", "collectioncount", batch, &res); err != nil {
+			return nil, err
+		}
+
+		found, err := inOrder(batch, res.Response.CollectionDetails, func(d CollectionDetails) int { return d.ID })
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, found...)
+	}
+	return details, nil
+}
+
+func (c *Client) postIDs(ctx context.Context, path, countField string, ids []int, out any) error {
+	form := url.Values{}
+	form.Set(countField, strconv.Itoa(len(ids)))
+	for i, id := range ids {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), strconv.Itoa(id))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(c.APIBaseURL, path), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("steam web api returned status %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode steam web api response: %w", err)
+	}
+	return nil
+}
+
+// inOrder matches the returned entries to the requested IDs, since Steam does
+// not promise to keep the request order.
+func inOrder[T any](ids []int, entries []T, id func(T) int) ([]T, error) {
+	byID := make(map[int]T, len(entries))
+	for _, e := range entries {
+		byID[id(e)] = e
+	}
+
+	ordered := make([]T, len(ids))
+	for i, requested := range ids {
+		e, ok := byID[requested]
+		if !ok {
+			return nil, fmt.Errorf("steam web api response is missing %d", requested)
+		}
+		ordered[i] = e
+	}
+	return ordered, nil
+}
+
+// flexInt accepts numbers that Steam sometimes encodes as strings.
+type flexInt int64
+
+func (f *flexInt) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*f = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	*f = flexInt(n)
+	return nil
+}
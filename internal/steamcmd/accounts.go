@@ -0,0 +1,259 @@
+package steamcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Account struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// PasswordEnv and PasswordFile read the password from an environment
+	// variable or a file instead of storing it in the accounts file.
+	PasswordEnv  string `json:"password_env"`
+	PasswordFile string `json:"password_file"`
+	// SharedSecret is the base64 encoded mobile authenticator secret used
+	// to generate Steam Guard codes.
+	SharedSecret string `json:"shared_secret"`
+	// Apps lists the app IDs this account owns. An empty list means the
+	// account may be used for any app.
+	Apps []int `json:"apps"`
+	// MaxConcurrent caps parallel sessions; Steam logs out other sessions of
+	// the same account, so it defaults to 1.
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+func (a *Account) serves(appID int) bool {
+	return len(a.Apps) == 0 || slices.Contains(a.Apps, appID)
+}
+
+type AccountsConfig struct {
+	Accounts []Account `json:"accounts"`
+	// AnonymousApps may be downloaded anonymously when every account able to
+	// serve them is busy or cooling down.
+	AnonymousApps []int `json:"anonymous_apps"`
+	// LoginPolicies overrides the default login policy for individual apps.
+	LoginPolicies map[int]LoginPolicy `json:"login_policies"`
+}
+
+func LoadAccounts(path string) (AccountsConfig, error) {
+	var cfg AccountsConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse accounts file: %w", err)
+	}
+
+	for i := range cfg.Accounts {
+		a := &cfg.Accounts[i]
+		if a.Username == "" {
+			return cfg, fmt.Errorf("account %d has no username", i)
+		}
+		if err := a.resolvePassword(); err != nil {
+			return cfg, fmt.Errorf("account %s: %w", a.Username, err)
+		}
+		if err := a.Validate(); err != nil {
+			return cfg, fmt.Errorf("account %d: %w", i, err)
+		}
+		if a.SharedSecret != "" {
+			if _, err := GenerateGuardCode(a.SharedSecret, time.Now()); err != nil {
+				return cfg, fmt.Errorf("account %s: %w", a.Username, err)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// Validate rejects credentials that cannot be sent to steamcmd's login
+// command: whitespace would split them into separate arguments and a newline
+// would start another command.
+func (a *Account) Validate() error {
+	if !safeArgument(a.Username) {
+		return errors.New("username contains whitespace or control characters")
+	}
+	if !safeArgument(a.Password) {
+		return errors.New("password contains whitespace or control characters")
+	}
+	return nil
+}
+
+func (a *Account) resolvePassword() error {
+	switch {
+	case a.PasswordEnv != "":
+		password, ok := os.LookupEnv(a.PasswordEnv)
+		if !ok {
+			return fmt.Errorf("environment variable %s is not set", a.PasswordEnv)
+		}
+		a.Password = password
+	case a.PasswordFile != "":
+		password, err := ReadSecretFile(a.PasswordFile)
+		if err != nil {
+			return err
+		}
+		a.Password = password
+	}
+	return nil
+}
+
+// ReadSecretFile reads a secret stored on its own in a file, ignoring
+// surrounding whitespace.
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+type accountState struct {
+	Account
+	active    int
+	coolUntil time.Time
+}
+
+type AccountPool struct {
+	cooldown      time.Duration
+	anonymousApps map[int]bool
+
+	mu       sync.Mutex
+	accounts []*accountState
+	changed  chan struct{}
+}
+
+func NewAccountPool(cfg AccountsConfig, cooldown time.Duration) *AccountPool {
+	p := &AccountPool{
+		cooldown:      cooldown,
+		anonymousApps: make(map[int]bool),
+		changed:       make(chan struct{}),
+	}
+
+	for _, a := range cfg.Accounts {
+		if a.MaxConcurrent < 1 {
+			a.MaxConcurrent = 1
+		}
+		p.accounts = append(p.accounts, &accountState{Account: a})
+	}
+	for _, appID := range cfg.AnonymousApps {
+		p.anonymousApps[appID] = true
+	}
+
+	return p
+}
+
+// Serves reports whether any configured account can download appID.
+func (p *AccountPool) Serves(appID int) bool {
+	for _, a := range p.accounts {
+		if a.serves(appID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Acquire leases an account able to download appID. A nil account means the
+// download should log in anonymously, which happens when no account serves
+// the app or when the app allows anonymous access and every account is busy.
+// Otherwise Acquire waits for an account to become available.
+func (p *AccountPool) Acquire(ctx context.Context, appID int) (*Account, func(error), error) {
+	for {
+		p.mu.Lock()
+		state, wait, ok := p.pick(appID)
+		if ok && state != nil {
+			state.active++
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		if ok {
+			if state == nil {
+				return nil, func(error) {}, nil
+			}
+			return &state.Account, p.releaseFunc(state), nil
+		}
+
+		if err := waitFor(ctx, changed, wait); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// waitFor blocks until changed is closed, wait elapses (if positive) or ctx
+// is done.
+func waitFor(ctx context.Context, changed <-chan struct{}, wait time.Duration) error {
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-changed:
+	case <-timeout:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
+// pick must be called with p.mu held. It returns the least loaded usable
+// account, or ok=false together with the time until a cooldown expires when
+// the caller has to wait.
+func (p *AccountPool) pick(appID int) (state *accountState, wait time.Duration, ok bool) {
+	now := time.Now()
+	candidates := 0
+
+	for _, a := range p.accounts {
+		if !a.serves(appID) {
+			continue
+		}
+		candidates++
+
+		if a.coolUntil.After(now) {
+			if until := a.coolUntil.Sub(now); wait == 0 || until < wait {
+				wait = until
+			}
+			continue
+		}
+		if a.active >= a.MaxConcurrent {
+			continue
+		}
+		if state == nil || a.active < state.active {
+			state = a
+		}
+	}
+
+	if state != nil || candidates == 0 || p.anonymousApps[appID] {
+		return state, 0, true
+	}
+	return nil, wait, false
+}
+
+func (p *AccountPool) releaseFunc(state *accountState) func(error) {
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			state.active--
+			if errors.Is(err, ErrRateLimited) && p.cooldown > 0 {
+				state.coolUntil = time.Now().Add(p.cooldown)
+			}
+
+			close(p.changed)
+			p.changed = make(chan struct{})
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package steamcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+type AppOptions struct {
+	Beta         string
+	BetaPassword string
+	Validate     bool
+	Platform     Platform
+	// InstallDir receives the app; it defaults to AppContentPath.
+	InstallDir string
+	// Account to log in with; nil logs in anonymously.
+	Account    *Account
+	OnProgress func(percent float64)
+}
+
+// AppContentPath is the default install directory of an app. Builds for a
+// forced platform are kept apart from the host's.
+func (s *SteamCMD) AppContentPath(appID int, platform Platform) string {
+	dir := fmt.Sprint(appID)
+	if platform != "" {
+		dir += "_" + string(platform)
+	}
+	return filepath.Join(s.contentRoot(), "apps", dir)
+}
+
+// DownloadApp installs or updates an app, typically a dedicated server, with
+// app_update. Transient failures are retried according to s.RetryPolicy.
+func (s *SteamCMD) DownloadApp(ctx context.Context, appID int, opts AppOptions) error {
+	// The options end up on steamcmd's stdin, where whitespace would start
+	// another command.
+	if !safeArgument(opts.Beta) || !safeArgument(opts.BetaPassword) {
+		return fmt.Errorf("beta and beta password must not contain whitespace or control characters")
+	}
+
+	installDir := opts.InstallDir
+	if installDir == "" {
+		installDir = s.AppContentPath(appID, opts.Platform)
+	}
+
+	installDir, err := filepath.Abs(installDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve install directory: %w", err)
+	}
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		return fmt.Errorf("failed to create install directory: %w", err)
+	}
+
+	return s.withRetries(ctx, fmt.Sprintf("app %d", appID), func() error {
+		return s.withCachedLogin(opts.Account, func(cached bool) error {
+			return s.runAppSession(ctx, appID, installDir, opts, cached)
+		})
+	})
+}
+
+func (s *SteamCMD) runAppSession(ctx context.Context, appID int, installDir string, opts AppOptions, cachedLogin bool) error {
+	command := fmt.Sprintf("app_update %d", appID)
+	if opts.Beta != "" {
+		command += " -beta " + opts.Beta
+	}
+	if opts.BetaPassword != "" {
+		command += " -betapassword " + opts.BetaPassword
+	}
+	if opts.Validate {
+		command += " validate"
+	}
+
+	sessionCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	parser := newOutputParser()
+
+	runErr := s.runSession(sessionCtx, cancel, sessionSpec{
+		installDir: installDir,
+		platform:   opts.Platform,
+		account:    opts.Account,
+		commands:   []string{command},
+		secrets:    []string{opts.BetaPassword},
+		onLine: func(line string) {
+			parser.parseLine(line)
+			if opts.OnProgress == nil {
+				return
+			}
+			if percent, ok := parseProgress(line); ok {
+				opts.OnProgress(percent)
+			}
+		},
+	}, cachedLogin)
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("steamcmd cancelled: %w", context.Cause(ctx))
+	}
+	if cause := context.Cause(sessionCtx); cause != nil {
+		return cause
+	}
+
+	return parser.appErr(appID, runErr)
+}
+
+func safeArgument(value string) bool {
+	return !strings.ContainsFunc(value, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	})
+}
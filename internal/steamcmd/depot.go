@@ -0,0 +1,110 @@
+package steamcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+type DepotOptions struct {
+	// ManifestID selects a historical build; zero downloads the current one.
+	ManifestID uint64
+	Platform   Platform
+	// Account to log in with; nil logs in anonymously.
+	Account    *Account
+	OnProgress func(percent float64)
+}
+
+// DepotContentPath is where a depot build is kept once downloaded. Every
+// manifest gets its own directory so older builds are never mixed with newer
+// files.
+func (s *SteamCMD) DepotContentPath(appID, depotID int, manifestID uint64) string {
+	manifest := "latest"
+	if manifestID != 0 {
+		manifest = strconv.FormatUint(manifestID, 10)
+	}
+	return filepath.Join(s.contentRoot(), "depots", fmt.Sprint(appID), fmt.Sprint(depotID), manifest)
+}
+
+// DownloadDepot fetches a depot with download_depot and returns the
+// directory holding it. A workshop item's historical manifests live in the
+// depot with the same ID as its app. Manifests are immutable, so one that is
+// already on disk is not downloaded again.
+func (s *SteamCMD) DownloadDepot(ctx context.Context, appID, depotID int, opts DepotOptions) (string, error) {
+	target := s.DepotContentPath(appID, depotID, opts.ManifestID)
+	if opts.ManifestID != 0 {
+		if _, err := os.Stat(target); err == nil {
+			return target, nil
+		}
+	}
+
+	var downloaded string
+	err := s.withRetries(ctx, fmt.Sprintf("depot %d of app %d", depotID, appID), func() error {
+		return s.withCachedLogin(opts.Account, func(cached bool) error {
+			var err error
+			downloaded, err = s.runDepotSession(ctx, appID, depotID, opts, cached)
+			return err
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(downloaded) {
+		downloaded = filepath.Join(s.InstallPath, downloaded)
+	}
+
+	if err := os.RemoveAll(target); err != nil {
+		return "", fmt.Errorf("failed to clear depot directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create depot directory: %w", err)
+	}
+	if err := os.Rename(downloaded, target); err != nil {
+		return "", fmt.Errorf("failed to move depot download: %w", err)
+	}
+
+	return target, nil
+}
+
+func (s *SteamCMD) runDepotSession(ctx context.Context, appID, depotID int, opts DepotOptions, cachedLogin bool) (string, error) {
+	command := fmt.Sprintf("download_depot %d %d", appID, depotID)
+	if opts.ManifestID != 0 {
+		command += fmt.Sprintf(" %d", opts.ManifestID)
+	}
+
+	sessionCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	parser := newOutputParser()
+
+	runErr := s.runSession(sessionCtx, cancel, sessionSpec{
+		installDir: s.ContentRoot,
+		platform:   opts.Platform,
+		account:    opts.Account,
+		commands:   []string{command},
+		onLine: func(line string) {
+			parser.parseLine(line)
+			if opts.OnProgress == nil {
+				return
+			}
+			if percent, ok := parseProgress(line); ok {
+				opts.OnProgress(percent)
+			}
+		},
+	}, cachedLogin)
+
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("steamcmd cancelled: %w", context.Cause(ctx))
+	}
+	if cause := context.Cause(sessionCtx); cause != nil {
+		return "", cause
+	}
+
+	if err := parser.depotErr(depotID, runErr); err != nil {
+		return "", err
+	}
+	return parser.depotPath, nil
+}
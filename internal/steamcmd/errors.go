@@ -0,0 +1,232 @@
+package steamcmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Error struct {
+	code string
+	msg  string
+}
+
+func (e *Error) Error() string { return e.msg }
+
+// Code is a stable, machine readable identifier suitable for API responses.
+func (e *Error) Code() string { return e.code }
+
+var (
+	ErrTimeout          = &Error{code: "timeout", msg: "steamcmd: download timed out"}
+	ErrAccessDenied     = &Error{code: "access_denied", msg: "steamcmd: access denied"}
+	ErrNoSubscription   = &Error{code: "no_subscription", msg: "steamcmd: account does not own the app"}
+	ErrItemNotFound     = &Error{code: "item_not_found", msg: "steamcmd: item not found"}
+	ErrManifestNotFound = &Error{code: "manifest_not_found", msg: "steamcmd: manifest not available"}
+	ErrLoginFailed      = &Error{code: "login_failed", msg: "steamcmd: login failed"}
+	ErrNoAccount        = &Error{code: "no_account", msg: "steamcmd: no account configured for app"}
+	ErrRateLimited      = &Error{code: "rate_limited", msg: "steamcmd: rate limited by Steam"}
+	ErrDownloadFailed   = &Error{code: "download_failed", msg: "steamcmd: download failed"}
+)
+
+type DownloadError struct {
+	WorkshopID int
+	Reason     string
+	Err        error
+}
+
+func (e *DownloadError) Error() string {
+	if e.WorkshopID == 0 {
+		return fmt.Sprintf("%v (%s)", e.Err, e.Reason)
+	}
+	return fmt.Sprintf("%v: item %d (%s)", e.Err, e.WorkshopID, e.Reason)
+}
+
+func (e *DownloadError) Unwrap() error { return e.Err }
+
+var (
+	itemFailedRegex  = regexp.MustCompile(`ERROR! Download item (\d+) failed \(([^)]+)\)`)
+	itemSuccessRegex = regexp.MustCompile(`Success\. Downloaded item (\d+)`)
+	itemStartRegex   = regexp.MustCompile(`Downloading item (\d+)`)
+	appFailedRegex   = regexp.MustCompile(`ERROR! Failed to install app '(\d+)' \(([^)]+)\)`)
+	appSuccessRegex  = regexp.MustCompile(`Success! App '(\d+)' (?:fully installed|already up to date)`)
+	depotFailedRegex = regexp.MustCompile(`Depot download failed : (.+)`)
+	depotDoneRegex   = regexp.MustCompile(`Depot download complete : "([^"]+)"`)
+	loginFailedRegex = regexp.MustCompile(`(?i)(?:logging in|login).*FAILED.*?\(([^)]+)\)|FAILED login with result code (.+)`)
+	genericErrRegex  = regexp.MustCompile(`^ERROR!? \(([^)]+)\)`)
+)
+
+func classifyReason(reason string) error {
+	r := strings.ToLower(reason)
+	switch {
+	case strings.Contains(r, "timeout"):
+		return ErrTimeout
+	case strings.Contains(r, "rate limit"):
+		return ErrRateLimited
+	case strings.Contains(r, "access denied"):
+		return ErrAccessDenied
+	case strings.Contains(r, "no subscription"):
+		return ErrNoSubscription
+	case strings.Contains(r, "manifest"):
+		return ErrManifestNotFound
+	case strings.Contains(r, "not found"):
+		return ErrItemNotFound
+	case strings.Contains(r, "password"), strings.Contains(r, "logon"),
+		strings.Contains(r, "auth code"), strings.Contains(r, "two-factor"):
+		return ErrLoginFailed
+	default:
+		return ErrDownloadFailed
+	}
+}
+
+// outputParser collects the outcome of a steamcmd run from its stdout, since
+// steamcmd frequently exits with status 0 even when every command failed.
+type outputParser struct {
+	itemErrors map[int]error
+	succeeded  map[int]bool
+	installed  map[int]bool
+	// depotPath is where steamcmd reported a download_depot result.
+	depotPath string
+	loginErr  error
+	otherErr  error
+
+	// current is the item steamcmd is working on, used to attribute
+	// progress lines which do not mention an item ID.
+	current   int
+	onStart   func(workshopID int)
+	onSuccess func(workshopID int)
+}
+
+func newOutputParser() *outputParser {
+	return &outputParser{
+		itemErrors: make(map[int]error),
+		succeeded:  make(map[int]bool),
+		installed:  make(map[int]bool),
+	}
+}
+
+func (p *outputParser) parseLine(line string) {
+	if m := itemFailedRegex.FindStringSubmatch(line); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		p.itemErrors[id] = &DownloadError{WorkshopID: id, Reason: m[2], Err: classifyReason(m[2])}
+		return
+	}
+
+	if m := itemSuccessRegex.FindStringSubmatch(line); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		p.succeeded[id] = true
+		delete(p.itemErrors, id)
+		if p.onSuccess != nil {
+			p.onSuccess(id)
+		}
+		return
+	}
+
+	if m := itemStartRegex.FindStringSubmatch(line); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		if id != p.current {
+			p.current = id
+			if p.onStart != nil {
+				p.onStart(id)
+			}
+		}
+		return
+	}
+
+	if m := loginFailedRegex.FindStringSubmatch(line); m != nil {
+		reason := strings.TrimSpace(m[1] + m[2])
+		err := classifyReason(reason)
+		if err == ErrDownloadFailed {
+			err = ErrLoginFailed
+		}
+		p.loginErr = &DownloadError{Reason: reason, Err: err}
+		return
+	}
+
+	if m := appSuccessRegex.FindStringSubmatch(line); m != nil {
+		id, _ := strconv.Atoi(m[1])
+		p.installed[id] = true
+		return
+	}
+
+	if m := appFailedRegex.FindStringSubmatch(line); m != nil {
+		p.otherErr = &DownloadError{Reason: m[2], Err: classifyReason(m[2])}
+		return
+	}
+
+	if m := depotDoneRegex.FindStringSubmatch(line); m != nil {
+		p.depotPath = m[1]
+		return
+	}
+
+	if m := depotFailedRegex.FindStringSubmatch(line); m != nil {
+		reason := strings.TrimSpace(m[1])
+		p.otherErr = &DownloadError{Reason: reason, Err: classifyReason(reason)}
+		return
+	}
+
+	if m := genericErrRegex.FindStringSubmatch(line); m != nil {
+		p.otherErr = &DownloadError{Reason: m[1], Err: classifyReason(m[1])}
+	}
+}
+
+// itemErr reports the failure recorded for workshopID, falling back to login
+// or unattributed errors that would have prevented the download. An item
+// without any of those still failed, since steamcmd's exit status cannot be
+// trusted.
+func (p *outputParser) itemErr(workshopID int) error {
+	if err, ok := p.itemErrors[workshopID]; ok {
+		return err
+	}
+	if p.succeeded[workshopID] {
+		return nil
+	}
+	if p.loginErr != nil {
+		return p.loginErr
+	}
+	if p.otherErr != nil {
+		return p.otherErr
+	}
+	return &DownloadError{WorkshopID: workshopID, Reason: "no result reported", Err: ErrDownloadFailed}
+}
+
+// hasOutcome reports whether steamcmd printed a result for workshopID.
+func (p *outputParser) hasOutcome(workshopID int) bool {
+	_, failed := p.itemErrors[workshopID]
+	return failed || p.succeeded[workshopID]
+}
+
+// appErr reports the outcome of an app_update session. Without an explicit
+// success line the run is treated as failed, since steamcmd's exit status
+// cannot be trusted.
+func (p *outputParser) appErr(appID int, runErr error) error {
+	switch {
+	case p.loginErr != nil:
+		return p.loginErr
+	case p.otherErr != nil:
+		return p.otherErr
+	case p.installed[appID]:
+		return nil
+	case runErr != nil:
+		return fmt.Errorf("steamcmd execution failed: %w", runErr)
+	default:
+		return &DownloadError{Reason: fmt.Sprintf("app %d: no success reported", appID), Err: ErrDownloadFailed}
+	}
+}
+
+// depotErr reports the outcome of a download_depot session, which, like
+// app_update, only counts as successful once steamcmd says so.
+func (p *outputParser) depotErr(depotID int, runErr error) error {
+	switch {
+	case p.loginErr != nil:
+		return p.loginErr
+	case p.otherErr != nil:
+		return p.otherErr
+	case p.depotPath != "":
+		return nil
+	case runErr != nil:
+		return fmt.Errorf("steamcmd execution failed: %w", runErr)
+	default:
+		return &DownloadError{Reason: fmt.Sprintf("depot %d: no success reported", depotID), Err: ErrDownloadFailed}
+	}
+}
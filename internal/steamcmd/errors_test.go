@@ -0,0 +1,189 @@
+package steamcmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   error
+	}{
+		{"Timeout", ErrTimeout},
+		{"Rate Limit Exceeded", ErrRateLimited},
+		{"Access Denied", ErrAccessDenied},
+		{"No subscription", ErrNoSubscription},
+		{"Manifest not available", ErrManifestNotFound},
+		{"File Not Found", ErrItemNotFound},
+		{"Invalid Password", ErrLoginFailed},
+		{"Two-factor code mismatch", ErrLoginFailed},
+		{"Disk write failure", ErrDownloadFailed},
+	}
+	for _, tt := range tests {
+		if got := classifyReason(tt.reason); got != tt.want {
+			t.Errorf("classifyReason(%q) = %v, want %v", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestItemOutcomes(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		id      int
+		want    error // nil for success
+		outcome bool
+	}{
+		{
+			name:    "success",
+			lines:   []string{"Downloading item 1 ...", "Success. Downloaded item 1 to \"/x/1\" (10 bytes)"},
+			id:      1,
+			outcome: true,
+		},
+		{
+			name:    "failure",
+			lines:   []string{"ERROR! Download item 1 failed (Access Denied)."},
+			id:      1,
+			want:    ErrAccessDenied,
+			outcome: true,
+		},
+		{
+			name:    "success after failure",
+			lines:   []string{"ERROR! Download item 1 failed (Timeout).", "Success. Downloaded item 1 to \"/x/1\""},
+			id:      1,
+			outcome: true,
+		},
+		{
+			name:  "no result reported",
+			lines: []string{"Downloading item 1 ...", "Success. Downloaded item 2 to \"/x/2\""},
+			id:    1,
+			want:  ErrDownloadFailed,
+		},
+		{
+			name:  "login failure",
+			lines: []string{"Logging in user 'bob' to Steam Public...FAILED (Invalid Password)"},
+			id:    1,
+			want:  ErrLoginFailed,
+		},
+		{
+			name:  "result code login failure",
+			lines: []string{"FAILED login with result code Rate Limit Exceeded"},
+			id:    1,
+			want:  ErrRateLimited,
+		},
+		{
+			name:  "unattributed error",
+			lines: []string{"ERROR! (No subscription)"},
+			id:    1,
+			want:  ErrNoSubscription,
+		},
+		{
+			name:    "unattributed error after success",
+			lines:   []string{"Success. Downloaded item 1 to \"/x/1\"", "ERROR! (Timeout)"},
+			id:      1,
+			outcome: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newOutputParser()
+			for _, line := range tt.lines {
+				p.parseLine(line)
+			}
+
+			err := p.itemErr(tt.id)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("itemErr(%d) = %v, want %v", tt.id, err, tt.want)
+			}
+			if got := p.hasOutcome(tt.id); got != tt.outcome {
+				t.Errorf("hasOutcome(%d) = %t, want %t", tt.id, got, tt.outcome)
+			}
+		})
+	}
+}
+
+func TestParserCallbacks(t *testing.T) {
+	p := newOutputParser()
+	var started, succeeded []int
+	p.onStart = func(id int) { started = append(started, id) }
+	p.onSuccess = func(id int) { succeeded = append(succeeded, id) }
+
+	for _, line := range []string{
+		"Downloading item 1 ...",
+		"Downloading item 1 ...",
+		"Success. Downloaded item 1 to \"/x/1\"",
+		"Downloading item 2 ...",
+		"ERROR! Download item 2 failed (Timeout).",
+	} {
+		p.parseLine(line)
+	}
+
+	if len(started) != 2 || started[0] != 1 || started[1] != 2 {
+		t.Errorf("started = %v, want [1 2]", started)
+	}
+	if len(succeeded) != 1 || succeeded[0] != 1 {
+		t.Errorf("succeeded = %v, want [1]", succeeded)
+	}
+	if p.current != 2 {
+		t.Errorf("current = %d, want 2", p.current)
+	}
+}
+
+func TestAppErr(t *testing.T) {
+	runErr := errors.New("exit status 8")
+
+	tests := []struct {
+		name   string
+		lines  []string
+		runErr error
+		want   error // nil for success
+	}{
+		{"installed", []string{"Success! App '4020' fully installed."}, nil, nil},
+		{"up to date", []string{"Success! App '4020' already up to date."}, runErr, nil},
+		{"failed", []string{"ERROR! Failed to install app '4020' (No subscription)"}, nil, ErrNoSubscription},
+		{"other app", []string{"Success! App '4000' fully installed."}, nil, ErrDownloadFailed},
+		{"exit status", nil, runErr, runErr},
+		{"silent", nil, nil, ErrDownloadFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newOutputParser()
+			for _, line := range tt.lines {
+				p.parseLine(line)
+			}
+			err := p.appErr(4020, tt.runErr)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("appErr = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDepotErr(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		want     error // nil for success
+		wantPath string
+	}{
+		{"complete", []string{`Depot download complete : "/steam/linux32/steamapps/content/app_4000/depot_4001" (12 files, manifest 123)`}, nil, "/steam/linux32/steamapps/content/app_4000/depot_4001"},
+		{"failed", []string{"Depot download failed : Manifest not available"}, ErrManifestNotFound, ""},
+		{"silent", nil, ErrDownloadFailed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newOutputParser()
+			for _, line := range tt.lines {
+				p.parseLine(line)
+			}
+			err := p.depotErr(4001, nil)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("depotErr = %v, want %v", err, tt.want)
+			}
+			if p.depotPath != tt.wantPath {
+				t.Errorf("depotPath = %q, want %q", p.depotPath, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package steamcmd
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const guardCodeAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+var (
+	guardPromptRegex = regexp.MustCompile(`(?i)(steam guard code|two-factor code)\s*:\s*$`)
+
+	ErrNoGuardPrompt = errors.New("no steamcmd login is waiting for a Steam Guard code for this account")
+)
+
+// GenerateGuardCode computes the Steam Guard mobile authenticator code for a
+// base64 encoded shared secret at time t.
+func GenerateGuardCode(sharedSecret string, t time.Time) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(sharedSecret)
+	if err != nil {
+		return "", fmt.Errorf("invalid shared secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/30))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	code := make([]byte, 5)
+	for i := range code {
+		code[i] = guardCodeAlphabet[value%uint32(len(guardCodeAlphabet))]
+		value /= uint32(len(guardCodeAlphabet))
+	}
+
+	return string(code), nil
+}
+
+type GuardPrompt struct {
+	Username    string    `json:"username"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+type guardRequest struct {
+	GuardPrompt
+	code string
+	done chan struct{}
+}
+
+// GuardBroker hands Steam Guard codes entered by an operator to steamcmd
+// logins that are blocked waiting for one, e.g. for email based Steam Guard.
+type GuardBroker struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*guardRequest
+}
+
+func NewGuardBroker(timeout time.Duration) *GuardBroker {
+	return &GuardBroker{timeout: timeout, pending: make(map[string]*guardRequest)}
+}
+
+func (b *GuardBroker) Pending() []GuardPrompt {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prompts := make([]GuardPrompt, 0, len(b.pending))
+	for _, req := range b.pending {
+		prompts = append(prompts, req.GuardPrompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].RequestedAt.Before(prompts[j].RequestedAt) })
+	return prompts
+}
+
+func (b *GuardBroker) Submit(username, code string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	req, ok := b.pending[username]
+	if !ok {
+		return ErrNoGuardPrompt
+	}
+
+	req.code = code
+	close(req.done)
+	delete(b.pending, username)
+	return nil
+}
+
+func (b *GuardBroker) wait(ctx context.Context, username string) (string, error) {
+	b.mu.Lock()
+	req, ok := b.pending[username]
+	if !ok {
+		req = &guardRequest{
+			GuardPrompt: GuardPrompt{Username: username, RequestedAt: time.Now()},
+			done:        make(chan struct{}),
+		}
+		b.pending[username] = req
+		log.Printf("🔐 steamcmd is waiting for a Steam Guard code for %s", username)
+	}
+	b.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if b.timeout > 0 {
+		timer := time.NewTimer(b.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-req.done:
+		return req.code, nil
+	case <-timeout:
+		b.abandon(req)
+		return "", fmt.Errorf("no code submitted within %s", b.timeout)
+	case <-ctx.Done():
+		b.abandon(req)
+		return "", context.Cause(ctx)
+	}
+}
+
+func (b *GuardBroker) abandon(req *guardRequest) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending[req.Username] == req {
+		delete(b.pending, req.Username)
+	}
+}
+
+// guardResponder answers the Steam Guard prompts of a single steamcmd
+// session, either from the account's shared secret or through the broker.
+type guardResponder struct {
+	ctx     context.Context
+	account *Account
+	broker  *GuardBroker
+	stdin   io.Writer
+	abort   context.CancelCauseFunc
+
+	prompted bool
+}
+
+func (g *guardResponder) onLine(string) {
+	g.prompted = false
+}
+
+func (g *guardResponder) onPartial(text string) {
+	if g.prompted || !guardPromptRegex.MatchString(text) {
+		return
+	}
+	g.prompted = true
+	go g.respond()
+}
+
+func (g *guardResponder) respond() {
+	code, err := g.code()
+	if err != nil {
+		g.abort(&DownloadError{Reason: "Steam Guard: " + err.Error(), Err: ErrLoginFailed})
+		return
+	}
+
+	if _, err := io.WriteString(g.stdin, code+"\n"); err != nil {
+		g.abort(&DownloadError{Reason: "Steam Guard: " + err.Error(), Err: ErrLoginFailed})
+	}
+}
+
+func (g *guardResponder) code() (string, error) {
+	return GuardCode(g.ctx, g.account, g.broker)
+}
+
+// GuardCode produces a Steam Guard code for account from its shared secret
+// or, without one, by waiting for an administrator to submit it to broker.
+func GuardCode(ctx context.Context, account *Account, broker *GuardBroker) (string, error) {
+	switch {
+	case account == nil:
+		return "", errors.New("code requested for an anonymous login")
+	case account.SharedSecret != "":
+		return GenerateGuardCode(account.SharedSecret, time.Now())
+	case broker != nil:
+		return broker.wait(ctx, account.Username)
+	default:
+		return "", errors.New("no shared secret configured for " + account.Username)
+	}
+}
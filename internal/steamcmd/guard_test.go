@@ -0,0 +1,121 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenerateGuardCode(t *testing.T) {
+	// The RFC 4226 test secret "12345678901234567890".
+	const secret = "MTIzNDU2Nzg5MDEyMzQ1Njc4OTA="
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{0, "GG5F5"},
+		{29, "GG5F5"},
+		{30, "PV9M4"},
+		{1700000000, "R87JJ"},
+		{2000000000, "9N776"},
+	}
+	for _, tt := range tests {
+		got, err := GenerateGuardCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("GenerateGuardCode: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("GenerateGuardCode(t=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := GenerateGuardCode("not base64!", time.Now()); err == nil {
+		t.Error("GenerateGuardCode accepted an invalid secret")
+	}
+}
+
+func TestGuardBroker(t *testing.T) {
+	b := NewGuardBroker(time.Minute)
+
+	if err := b.Submit("alice", "12345"); !errors.Is(err, ErrNoGuardPrompt) {
+		t.Errorf("Submit without a prompt = %v, want %v", err, ErrNoGuardPrompt)
+	}
+
+	codes := make(chan string, 1)
+	go func() {
+		code, _ := GuardCode(context.Background(), &Account{Username: "alice"}, b)
+		codes <- code
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(b.Pending()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("login never asked for a code")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if pending := b.Pending(); len(pending) != 1 || pending[0].Username != "alice" {
+		t.Fatalf("Pending() = %+v", pending)
+	}
+
+	if err := b.Submit("alice", "ABCDE"); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if code := <-codes; code != "ABCDE" {
+		t.Errorf("code = %q, want ABCDE", code)
+	}
+	if pending := b.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() after Submit = %+v", pending)
+	}
+}
+
+func TestGuardBrokerGivesUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		timeout time.Duration
+	}{
+		{"timeout", context.Background(), time.Millisecond},
+		{"cancelled", ctx, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewGuardBroker(tt.timeout)
+			if _, err := GuardCode(tt.ctx, &Account{Username: "bob"}, b); err == nil {
+				t.Error("GuardCode returned without a code")
+			}
+			if pending := b.Pending(); len(pending) != 0 {
+				t.Errorf("abandoned prompt is still pending: %+v", pending)
+			}
+		})
+	}
+}
+
+func TestGuardCodeSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		account *Account
+		broker  *GuardBroker
+		wantErr bool
+	}{
+		{"anonymous", nil, NewGuardBroker(0), true},
+		{"shared secret", &Account{Username: "a", SharedSecret: "MTIzNDU2Nzg5MDEyMzQ1Njc4OTA="}, nil, false},
+		{"no secret and no broker", &Account{Username: "a"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GuardCode(context.Background(), tt.account, tt.broker)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GuardCode = %q, %v, want error: %t", code, err, tt.wantErr)
+			}
+			if !tt.wantErr && len(code) != 5 {
+				t.Errorf("code = %q, want 5 characters", code)
+			}
+		})
+	}
+}
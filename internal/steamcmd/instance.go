@@ -0,0 +1,98 @@
+package steamcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// installationEntries are the parts of a steamcmd installation an instance
+// needs to start; steamcmd bootstraps everything else into its own directory.
+var installationEntries = []string{"linux32", "linux64", "package"}
+
+// newInstance returns a copy of base with its own steamcmd installation in
+// dir, so concurrent sessions never share config, appcache or package state.
+// The installation is copied from base the first time.
+func newInstance(base *SteamCMD, dir string) (*SteamCMD, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create steamcmd instance directory: %w", err)
+	}
+
+	instance := *base
+	instance.InstallPath = dir
+	instance.ExePath = filepath.Join(dir, filepath.Base(base.ExePath))
+	instance.ContentRoot = dir
+	instance.sessions = newSessionCache(dir)
+
+	if _, err := os.Stat(instance.ExePath); err == nil {
+		return &instance, nil
+	}
+	if _, err := os.Stat(base.ExePath); err != nil {
+		// Nothing to copy yet; the binary is reported missing on first use.
+		return &instance, nil
+	}
+
+	entries := append([]string{filepath.Base(base.ExePath)}, installationEntries...)
+	for _, name := range entries {
+		src := filepath.Join(base.InstallPath, name)
+		if _, err := os.Lstat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := copyTree(src, filepath.Join(dir, name)); err != nil {
+			return nil, fmt.Errorf("failed to copy steamcmd into %s: %w", dir, err)
+		}
+	}
+
+	return &instance, nil
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
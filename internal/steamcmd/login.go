@@ -0,0 +1,121 @@
+package steamcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+type LoginPolicy string
+
+const (
+	// LoginAnonymous always logs in anonymously.
+	LoginAnonymous LoginPolicy = "anonymous"
+	// LoginAccount always uses an account from the pool.
+	LoginAccount LoginPolicy = "account"
+	// LoginAuto tries anonymously first and retries items that were denied
+	// with an owning account, remembering apps where that was necessary.
+	LoginAuto LoginPolicy = "auto"
+)
+
+func ParseLoginPolicy(s string) (LoginPolicy, error) {
+	switch p := LoginPolicy(s); p {
+	case LoginAnonymous, LoginAccount, LoginAuto:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown login policy %q", s)
+	}
+}
+
+// LoginPolicies resolves the policy for an app from explicit configuration or
+// the default. Apps under LoginAuto that were found to need an account are
+// cached on disk and resolve to LoginAccount from then on.
+type LoginPolicies struct {
+	defaultPolicy LoginPolicy
+	configured    map[int]LoginPolicy
+	cachePath     string
+
+	mu      sync.RWMutex
+	learned map[int]bool
+}
+
+func NewLoginPolicies(defaultPolicy LoginPolicy, configured map[int]LoginPolicy, cachePath string) (*LoginPolicies, error) {
+	for appID, policy := range configured {
+		if _, err := ParseLoginPolicy(string(policy)); err != nil {
+			return nil, fmt.Errorf("app %d: %w", appID, err)
+		}
+	}
+
+	l := &LoginPolicies{
+		defaultPolicy: defaultPolicy,
+		configured:    configured,
+		cachePath:     cachePath,
+		learned:       make(map[int]bool),
+	}
+
+	if cachePath == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read login policy cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.learned); err != nil {
+		return nil, fmt.Errorf("failed to parse login policy cache: %w", err)
+	}
+
+	return l, nil
+}
+
+func (l *LoginPolicies) For(appID int) LoginPolicy {
+	policy, ok := l.configured[appID]
+	if !ok {
+		policy = l.defaultPolicy
+	}
+	if policy != LoginAuto {
+		return policy
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.learned[appID] {
+		return LoginAccount
+	}
+	return LoginAuto
+}
+
+// learnRequiresAccount records that appID could only be downloaded with an
+// owning account so later downloads skip the anonymous attempt.
+func (l *LoginPolicies) learnRequiresAccount(appID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.learned[appID] {
+		return
+	}
+	l.learned[appID] = true
+	log.Printf("🔑 App %d requires an owning account, remembering for future downloads.", appID)
+
+	if l.cachePath == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(l.learned, "", "  ")
+	if err == nil {
+		err = os.WriteFile(l.cachePath, data, 0644)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to persist login policy cache: %v", err)
+	}
+}
+
+func needsAccount(err error) bool {
+	return errors.Is(err, ErrAccessDenied) || errors.Is(err, ErrNoSubscription)
+}
@@ -0,0 +1,290 @@
+package steamcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/vdf"
+)
+
+// InstalledItem is a workshop item as recorded in appworkshop_<appid>.acf.
+type InstalledItem struct {
+	WorkshopID  int       `json:"workshop_id"`
+	Size        int64     `json:"size"`
+	ManifestID  uint64    `json:"manifest_id,string"`
+	TimeUpdated time.Time `json:"time_updated"`
+	// Platform is set for content downloaded for a forced platform.
+	Platform Platform `json:"platform,omitempty"`
+	// Path is the item's content directory.
+	Path string `json:"-"`
+}
+
+type WorkshopManifest struct {
+	AppID      int
+	SizeOnDisk int64
+	Items      []InstalledItem
+}
+
+type InstalledDepot struct {
+	DepotID    int    `json:"depot_id"`
+	ManifestID uint64 `json:"manifest_id,string"`
+	Size       int64  `json:"size"`
+}
+
+// AppManifest is the state steamcmd keeps about an installed app in
+// appmanifest_<appid>.acf.
+type AppManifest struct {
+	AppID       int              `json:"app_id"`
+	Name        string           `json:"name"`
+	InstallDir  string           `json:"install_dir"`
+	BuildID     int64            `json:"build_id"`
+	Beta        string           `json:"beta,omitempty"`
+	SizeOnDisk  int64            `json:"size_on_disk"`
+	LastUpdated time.Time        `json:"last_updated"`
+	Depots      []InstalledDepot `json:"depots"`
+}
+
+func ReadWorkshopManifest(path string) (*WorkshopManifest, error) {
+	root, err := vdf.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := root.Get("AppWorkshop")
+	if state == nil {
+		return nil, fmt.Errorf("%s: missing AppWorkshop section", path)
+	}
+
+	m := &WorkshopManifest{
+		AppID:      int(state.Int("appid")),
+		SizeOnDisk: state.Int("SizeOnDisk"),
+	}
+	if installed := state.Get("WorkshopItemsInstalled"); installed != nil {
+		for _, item := range installed.Children {
+			id, err := strconv.Atoi(item.Key)
+			if err != nil || !item.IsObject {
+				continue
+			}
+			m.Items = append(m.Items, InstalledItem{
+				WorkshopID:  id,
+				Size:        item.Int("size"),
+				ManifestID:  item.Uint("manifest"),
+				TimeUpdated: unixTime(item.Int("timeupdated")),
+			})
+		}
+	}
+
+	return m, nil
+}
+
+func ReadAppManifest(path string) (*AppManifest, error) {
+	root, err := vdf.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := root.Get("AppState")
+	if state == nil {
+		return nil, fmt.Errorf("%s: missing AppState section", path)
+	}
+
+	m := &AppManifest{
+		AppID:       int(state.Int("appid")),
+		Name:        state.String("name"),
+		InstallDir:  state.String("installdir"),
+		BuildID:     state.Int("buildid"),
+		Beta:        state.String("UserConfig", "BetaKey"),
+		SizeOnDisk:  state.Int("SizeOnDisk"),
+		LastUpdated: unixTime(state.Int("LastUpdated")),
+	}
+	if depots := state.Get("InstalledDepots"); depots != nil {
+		for _, depot := range depots.Children {
+			id, err := strconv.Atoi(depot.Key)
+			if err != nil || !depot.IsObject {
+				continue
+			}
+			m.Depots = append(m.Depots, InstalledDepot{
+				DepotID:    id,
+				ManifestID: depot.Uint("manifest"),
+				Size:       depot.Int("size"),
+			})
+		}
+	}
+
+	return m, nil
+}
+
+func (s *SteamCMD) WorkshopManifestPath(appID int, platform Platform) string {
+	return filepath.Join(s.workshopRoot(platform), "steamapps", "workshop", fmt.Sprintf("appworkshop_%d.acf", appID))
+}
+
+// AppManifestPath is the manifest of an app installed into installDir.
+func AppManifestPath(installDir string, appID int) string {
+	return filepath.Join(installDir, "steamapps", fmt.Sprintf("appmanifest_%d.acf", appID))
+}
+
+// InstalledWorkshopItems lists the items steamcmd has recorded for appID. An
+// app without a manifest has no items.
+func (s *SteamCMD) InstalledWorkshopItems(appID int, platform Platform) ([]InstalledItem, error) {
+	m, err := ReadWorkshopManifest(s.WorkshopManifestPath(appID, platform))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.Items {
+		m.Items[i].Platform = platform
+	}
+	return m.Items, nil
+}
+
+// InstalledApp reads the manifest of an app installed into its default
+// directory. It returns nil if the app is not installed.
+func (s *SteamCMD) InstalledApp(appID int, platform Platform) (*AppManifest, error) {
+	m, err := ReadAppManifest(AppManifestPath(s.AppContentPath(appID, platform), appID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// WorkshopApps lists the apps any instance holds a workshop manifest for.
+func (p *Pool) WorkshopApps() ([]int, error) {
+	seen := make(map[int]bool)
+	for _, s := range p.instances {
+		for _, platform := range Platforms {
+			matches, err := filepath.Glob(filepath.Join(s.workshopRoot(platform), "steamapps", "workshop", "appworkshop_*.acf"))
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				var appID int
+				if _, err := fmt.Sscanf(filepath.Base(match), "appworkshop_%d.acf", &appID); err == nil {
+					seen[appID] = true
+				}
+			}
+		}
+	}
+
+	apps := make([]int, 0, len(seen))
+	for appID := range seen {
+		apps = append(apps, appID)
+	}
+	sort.Ints(apps)
+	return apps, nil
+}
+
+// InstalledWorkshopItems merges the items installed on every instance,
+// keeping the most recently updated copy of each item and platform. Items
+// whose content has been removed from disk are left out even though
+// steamcmd still lists them.
+func (p *Pool) InstalledWorkshopItems(appID int) ([]InstalledItem, error) {
+	latest := make(map[contentKey]InstalledItem)
+	for _, s := range p.instances {
+		for _, platform := range Platforms {
+			items, err := s.InstalledWorkshopItems(appID, platform)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				item.Path = s.GetWorkshopContentPath(appID, item.WorkshopID, platform)
+				if _, err := os.Stat(item.Path); err != nil {
+					continue
+				}
+				key := contentKey{appID: appID, workshopID: item.WorkshopID, platform: platform}
+				if prev, ok := latest[key]; !ok || item.TimeUpdated.After(prev.TimeUpdated) {
+					latest[key] = item
+				}
+			}
+		}
+	}
+
+	items := make([]InstalledItem, 0, len(latest))
+	for _, item := range latest {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].WorkshopID != items[j].WorkshopID {
+			return items[i].WorkshopID < items[j].WorkshopID
+		}
+		return items[i].Platform < items[j].Platform
+	})
+	return items, nil
+}
+
+// InstalledWorkshopItem returns the most recently updated copy of an item
+// any instance holds for platform.
+func (p *Pool) InstalledWorkshopItem(appID, workshopID int, platform Platform) (InstalledItem, bool, error) {
+	var (
+		latest InstalledItem
+		found  bool
+	)
+	for _, s := range p.instances {
+		items, err := s.InstalledWorkshopItems(appID, platform)
+		if err != nil {
+			return InstalledItem{}, false, err
+		}
+		for _, item := range items {
+			if item.WorkshopID != workshopID {
+				continue
+			}
+			item.Path = s.GetWorkshopContentPath(appID, workshopID, platform)
+			if _, err := os.Stat(item.Path); err != nil {
+				break
+			}
+			if !found || item.TimeUpdated.After(latest.TimeUpdated) {
+				latest, found = item, true
+			}
+			break
+		}
+	}
+	return latest, found, nil
+}
+
+// RemoveWorkshopItem deletes an item's content for every platform from every
+// instance and reports whether there was any.
+func (p *Pool) RemoveWorkshopItem(appID, workshopID int) (bool, error) {
+	removed := false
+	for _, s := range p.instances {
+		for _, platform := range Platforms {
+			path := s.GetWorkshopContentPath(appID, workshopID, platform)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return removed, fmt.Errorf("failed to remove workshop item %d: %w", workshopID, err)
+			}
+			removed = true
+		}
+	}
+
+	p.mu.Lock()
+	for _, platform := range Platforms {
+		delete(p.locations, contentKey{appID: appID, workshopID: workshopID, platform: platform})
+	}
+	p.mu.Unlock()
+
+	return removed, nil
+}
+
+// InstalledApp reads the manifest of the instance holding the app.
+func (p *Pool) InstalledApp(appID int, platform Platform) (*AppManifest, error) {
+	m, err := ReadAppManifest(AppManifestPath(p.AppContentPath(appID, platform), appID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return m, err
+}
+
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
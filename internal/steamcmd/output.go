@@ -0,0 +1,22 @@
+package steamcmd
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var progressRegex = regexp.MustCompile(`progress: (\d+(?:\.\d+)?)`)
+
+type ProgressFunc func(workshopID int, percent float64)
+
+func parseProgress(line string) (float64, bool) {
+	matches := progressRegex.FindStringSubmatch(line)
+	if len(matches) != 2 {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return percent, true
+}
@@ -0,0 +1,22 @@
+package steamcmd
+
+import "testing"
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line string
+		want float64
+		ok   bool
+	}{
+		{"Update state (0x61) downloading, progress: 45.52 (1234 / 5678)", 45.52, true},
+		{"Update state (0x5) verifying install, progress: 100", 100, true},
+		{"Downloading item 123 ...", 0, false},
+		{"progress: n/a", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseProgress(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseProgress(%q) = %v, %t, want %v, %t", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package steamcmd
+
+import "fmt"
+
+// Platform forces steamcmd to download content for an operating system other
+// than the host's, via +@sSteamCmdForcePlatformType. The zero value uses the
+// host platform.
+type Platform string
+
+const (
+	PlatformWindows Platform = "windows"
+	PlatformLinux   Platform = "linux"
+	PlatformMacOS   Platform = "macos"
+)
+
+// Platforms lists the host platform and every platform that can be forced.
+var Platforms = []Platform{"", PlatformWindows, PlatformLinux, PlatformMacOS}
+
+func ParsePlatform(value string) (Platform, error) {
+	switch p := Platform(value); p {
+	case "", PlatformWindows, PlatformLinux, PlatformMacOS:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown platform %q (expected windows, linux or macos)", value)
+	}
+}
@@ -0,0 +1,362 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// contentKey identifies downloaded content; workshopID holds the depot ID for
+// depots and is zero for apps. Workshop items and apps differ per platform.
+type contentKey struct {
+	appID      int
+	workshopID int
+	platform   Platform
+}
+
+// Pool leases independent steamcmd instances to concurrent downloads. With
+// more than one, every instance runs its own copy of steamcmd below
+// instances/<n>, so parallel sessions never touch the same config, appcache,
+// appworkshop_<appid>.acf or content directory.
+type Pool struct {
+	instances []*SteamCMD
+	free      chan *SteamCMD
+	accounts  *AccountPool
+	logins    *LoginPolicies
+
+	mu        sync.RWMutex
+	locations map[contentKey]*SteamCMD
+	depots    sync.Map
+}
+
+func NewPool(base *SteamCMD, size int, accounts *AccountPool, logins *LoginPolicies) (*Pool, error) {
+	if size < 1 {
+		size = 1
+	}
+	if accounts == nil {
+		accounts = NewAccountPool(AccountsConfig{}, 0)
+	}
+	if logins == nil {
+		logins, _ = NewLoginPolicies(LoginAuto, nil, "")
+	}
+
+	p := &Pool{
+		accounts:  accounts,
+		logins:    logins,
+		free:      make(chan *SteamCMD, size),
+		locations: make(map[contentKey]*SteamCMD),
+	}
+
+	for i := 0; i < size; i++ {
+		instance := base
+		if size > 1 {
+			dir, err := filepath.Abs(filepath.Join(base.InstallPath, "instances", fmt.Sprint(i)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve steamcmd instance directory: %w", err)
+			}
+			if instance, err = newInstance(base, dir); err != nil {
+				return nil, err
+			}
+		}
+
+		p.instances = append(p.instances, instance)
+		p.free <- instance
+	}
+
+	return p, nil
+}
+
+func (p *Pool) Size() int {
+	return len(p.instances)
+}
+
+func (p *Pool) Instances() []*SteamCMD {
+	return p.instances
+}
+
+// Health checks that the steamcmd binary is still installed.
+func (p *Pool) Health(ctx context.Context) error {
+	if _, err := os.Stat(p.instances[0].ExePath); err != nil {
+		return fmt.Errorf("steamcmd binary is missing: %w", err)
+	}
+	return nil
+}
+
+func (p *Pool) Acquire(ctx context.Context) (*SteamCMD, error) {
+	select {
+	case s := <-p.free:
+		return s, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+func (p *Pool) Release(s *SteamCMD) {
+	p.free <- s
+}
+
+func (p *Pool) DownloadWorkshopItem(ctx context.Context, appID, workshopID int, opts DownloadOptions) error {
+	return p.DownloadWorkshopItems(ctx, appID, []int{workshopID}, opts)[0].Err
+}
+
+// DownloadWorkshopItems downloads the items on a leased instance, logging in
+// according to the app's LoginPolicy.
+func (p *Pool) DownloadWorkshopItems(ctx context.Context, appID int, workshopIDs []int, opts DownloadOptions) []ItemResult {
+	switch p.logins.For(appID) {
+	case LoginAnonymous:
+		return p.download(ctx, appID, workshopIDs, opts, false)
+	case LoginAccount:
+		return p.download(ctx, appID, workshopIDs, opts, true)
+	}
+
+	canEscalate := p.accounts.Serves(appID)
+
+	// Denied items are only reported once the account attempt has settled.
+	anonymousOpts := opts
+	anonymousOpts.OnResult = func(r ItemResult) {
+		if canEscalate && needsAccount(r.Err) {
+			return
+		}
+		if opts.OnResult != nil {
+			opts.OnResult(r)
+		}
+	}
+
+	results := p.download(ctx, appID, workshopIDs, anonymousOpts, false)
+	if !canEscalate {
+		return results
+	}
+
+	var denied []int
+	index := make(map[int]int)
+	for i, r := range results {
+		if needsAccount(r.Err) {
+			denied = append(denied, r.WorkshopID)
+			index[r.WorkshopID] = i
+		}
+	}
+	if len(denied) == 0 {
+		return results
+	}
+
+	log.Printf("🔑 %d item(s) of app %d were denied anonymously, retrying with an account.", len(denied), appID)
+
+	learned := false
+	for _, r := range p.download(ctx, appID, denied, opts, true) {
+		results[index[r.WorkshopID]] = r
+		if r.Err == nil && !learned {
+			p.logins.learnRequiresAccount(appID)
+			learned = true
+		}
+	}
+
+	return results
+}
+
+func (p *Pool) download(ctx context.Context, appID int, workshopIDs []int, opts DownloadOptions, useAccount bool) []ItemResult {
+	s, account, release, err := p.lease(ctx, appID, useAccount)
+	if err != nil {
+		return failAll(workshopIDs, err, opts)
+	}
+
+	opts.Account = account
+	results := s.DownloadWorkshopItems(ctx, appID, workshopIDs, opts)
+
+	var sessionErr error
+	for _, r := range results {
+		if errors.Is(r.Err, ErrRateLimited) {
+			sessionErr = r.Err
+			break
+		}
+	}
+	release(sessionErr)
+
+	p.mu.Lock()
+	for _, r := range results {
+		if r.Err == nil {
+			p.locations[contentKey{appID: appID, workshopID: r.WorkshopID, platform: opts.Platform}] = s
+		}
+	}
+	p.mu.Unlock()
+
+	return results
+}
+
+// DownloadApp runs app_update on a leased instance following the app's
+// LoginPolicy and returns the directory the app was installed into.
+func (p *Pool) DownloadApp(ctx context.Context, appID int, opts AppOptions) (string, error) {
+	var path string
+	err := p.withLoginPolicy(appID, func(useAccount bool) error {
+		var err error
+		path, err = p.downloadApp(ctx, appID, opts, useAccount)
+		return err
+	})
+	return path, err
+}
+
+func (p *Pool) downloadApp(ctx context.Context, appID int, opts AppOptions, useAccount bool) (string, error) {
+	s, account, release, err := p.lease(ctx, appID, useAccount)
+	if err != nil {
+		return "", err
+	}
+
+	opts.Account = account
+	err = s.DownloadApp(ctx, appID, opts)
+	release(err)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.InstallDir != "" {
+		return opts.InstallDir, nil
+	}
+
+	p.mu.Lock()
+	p.locations[contentKey{appID: appID, platform: opts.Platform}] = s
+	p.mu.Unlock()
+
+	return s.AppContentPath(appID, opts.Platform), nil
+}
+
+// DownloadWorkshopManifest downloads a historical build of a workshop item,
+// which is served from the app's own depot.
+func (p *Pool) DownloadWorkshopManifest(ctx context.Context, appID, workshopID int, opts DepotOptions) (string, error) {
+	log.Printf("📌 Fetching manifest %d of item %d from depot %d.", opts.ManifestID, workshopID, appID)
+	return p.DownloadDepot(ctx, appID, appID, opts)
+}
+
+// DownloadDepot runs download_depot on a leased instance following the app's
+// LoginPolicy and returns the directory holding the depot build.
+func (p *Pool) DownloadDepot(ctx context.Context, appID, depotID int, opts DepotOptions) (string, error) {
+	// Concurrent requests for one depot would download it once per instance.
+	// Holding the lock, a later caller finds a pinned manifest the previous
+	// one stored and serves it without downloading it again.
+	lock, _ := p.depots.LoadOrStore(contentKey{appID: appID, workshopID: depotID}, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if opts.ManifestID != 0 {
+		for _, s := range p.instances {
+			path := s.DepotContentPath(appID, depotID, opts.ManifestID)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+
+	var path string
+	err := p.withLoginPolicy(appID, func(useAccount bool) error {
+		s, account, release, err := p.lease(ctx, appID, useAccount)
+		if err != nil {
+			return err
+		}
+
+		opts.Account = account
+		path, err = s.DownloadDepot(ctx, appID, depotID, opts)
+		release(err)
+		return err
+	})
+	return path, err
+}
+
+// withLoginPolicy calls download with or without an account according to
+// the app's LoginPolicy. Auto apps denied anonymously are retried with an
+// account and remembered as requiring one.
+func (p *Pool) withLoginPolicy(appID int, download func(useAccount bool) error) error {
+	policy := p.logins.For(appID)
+
+	err := download(policy == LoginAccount)
+	if policy == LoginAuto && needsAccount(err) && p.accounts.Serves(appID) {
+		log.Printf("🔑 App %d was denied anonymously, retrying with an account.", appID)
+		err = download(true)
+		if err == nil {
+			p.logins.learnRequiresAccount(appID)
+		}
+	}
+
+	return err
+}
+
+// lease acquires an account, when useAccount is set, and then an instance.
+// release must be called with the outcome of the download so rate limited
+// accounts are rested.
+func (p *Pool) lease(ctx context.Context, appID int, useAccount bool) (*SteamCMD, *Account, func(error), error) {
+	var account *Account
+	releaseAccount := func(error) {}
+
+	if useAccount {
+		if !p.accounts.Serves(appID) {
+			return nil, nil, nil, &DownloadError{Reason: fmt.Sprintf("app %d", appID), Err: ErrNoAccount}
+		}
+
+		var err error
+		account, releaseAccount, err = p.accounts.Acquire(ctx, appID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("no steam account available: %w", err)
+		}
+	}
+
+	s, err := p.Acquire(ctx)
+	if err != nil {
+		releaseAccount(nil)
+		return nil, nil, nil, fmt.Errorf("no steamcmd instance available: %w", err)
+	}
+
+	return s, account, func(err error) {
+		releaseAccount(err)
+		p.Release(s)
+	}, nil
+}
+
+// GetWorkshopContentPath resolves the instance holding an item: the one that
+// last downloaded it, or any instance that already has it on disk.
+func (p *Pool) GetWorkshopContentPath(appID, workshopID int, platform Platform) string {
+	p.mu.RLock()
+	s, ok := p.locations[contentKey{appID: appID, workshopID: workshopID, platform: platform}]
+	p.mu.RUnlock()
+	if ok {
+		return s.GetWorkshopContentPath(appID, workshopID, platform)
+	}
+
+	for _, s := range p.instances {
+		path := s.GetWorkshopContentPath(appID, workshopID, platform)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return p.instances[0].GetWorkshopContentPath(appID, workshopID, platform)
+}
+
+func (p *Pool) AppContentPath(appID int, platform Platform) string {
+	p.mu.RLock()
+	s, ok := p.locations[contentKey{appID: appID, platform: platform}]
+	p.mu.RUnlock()
+	if ok {
+		return s.AppContentPath(appID, platform)
+	}
+
+	for _, s := range p.instances {
+		path := s.AppContentPath(appID, platform)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return p.instances[0].AppContentPath(appID, platform)
+}
+
+func failAll(workshopIDs []int, err error, opts DownloadOptions) []ItemResult {
+	results := make([]ItemResult, len(workshopIDs))
+	for i, id := range workshopIDs {
+		results[i] = ItemResult{WorkshopID: id, Err: err}
+		if opts.OnResult != nil {
+			opts.OnResult(results[i])
+		}
+	}
+	return results
+}
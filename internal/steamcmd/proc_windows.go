@@ -0,0 +1,15 @@
+//go:build windows
+
+package steamcmd
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// setProcessGroup makes cancelling cmd terminate its whole process tree.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", fmt.Sprint(cmd.Process.Pid)).Run()
+	}
+}
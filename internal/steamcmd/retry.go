@@ -0,0 +1,65 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os/exec"
+	"time"
+)
+
+// IsTransient reports whether err is worth retrying. Failures that steamcmd
+// attributes to the item or the account, or failing to start the process at
+// all, will not go away on their own.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case isStartFailure(err):
+		return false
+	case errors.Is(err, ErrAccessDenied),
+		errors.Is(err, ErrNoSubscription),
+		errors.Is(err, ErrItemNotFound),
+		errors.Is(err, ErrManifestNotFound),
+		errors.Is(err, ErrLoginFailed):
+		return false
+	default:
+		return true
+	}
+}
+
+// isStartFailure reports whether err comes from starting a process, such as
+// a missing or non-executable binary.
+func isStartFailure(err error) bool {
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return true
+	}
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr) && pathErr.Op == "fork/exec"
+}
+
+// withRetries runs attempt until it succeeds, fails permanently or
+// s.RetryPolicy is exhausted.
+func (s *SteamCMD) withRetries(ctx context.Context, target string, attempt func() error) error {
+	n, err := s.RetryPolicy.Do(ctx, IsTransient, func(n int, err error, delay time.Duration) {
+		log.Printf("⚠️ steamcmd attempt %d/%d for %s failed: %v (retrying in %s)", n, s.RetryPolicy.MaxAttempts, target, err, delay.Round(time.Millisecond))
+	}, attempt)
+
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("steamcmd cancelled: %w", context.Cause(ctx))
+	case n > 1:
+		return fmt.Errorf("steamcmd failed after %d attempts: %w", n, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,46 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	missingBinary := exec.Command("/nonexistent/steamcmd.sh").Run()
+	notOnPath := exec.Command("steamcmd-that-does-not-exist").Run()
+	// The test binary itself exits with a non-zero status on an unknown flag.
+	exitStatus := exec.Command(os.Args[0], "-unknown-flag").Run()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"cancelled", fmt.Errorf("steamcmd cancelled: %w", context.Canceled), false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"access denied", &DownloadError{WorkshopID: 1, Reason: "Access Denied", Err: ErrAccessDenied}, false},
+		{"no subscription", &DownloadError{Err: ErrNoSubscription}, false},
+		{"item not found", &DownloadError{Err: ErrItemNotFound}, false},
+		{"manifest not found", &DownloadError{Err: ErrManifestNotFound}, false},
+		{"login failed", &DownloadError{Err: ErrLoginFailed}, false},
+		{"missing binary", fmt.Errorf("steamcmd execution failed: %w", missingBinary), false},
+		{"binary not on PATH", notOnPath, false},
+		{"timeout", &DownloadError{Err: ErrTimeout}, true},
+		{"rate limited", &DownloadError{Err: ErrRateLimited}, true},
+		{"generic failure", &DownloadError{Err: ErrDownloadFailed}, true},
+		{"exit status", fmt.Errorf("steamcmd execution failed: %w", exitStatus), true},
+		{"unknown", errors.New("connection reset"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
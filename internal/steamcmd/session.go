@@ -0,0 +1,218 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
+)
+
+var steamPromptRegex = regexp.MustCompile(`Steam>`)
+
+// session feeds commands to an interactive steamcmd over stdin, one per
+// "Steam>" prompt, so credentials never appear in the process arguments and
+// a Steam Guard prompt can never swallow the next command.
+type session struct {
+	stdin    io.Writer
+	commands []string
+	guard    *guardResponder
+
+	next   int
+	prompt int
+}
+
+func (s *session) onLine(line string) {
+	s.prompt = 0
+	s.guard.onLine(line)
+}
+
+func (s *session) onPartial(text string) {
+	prompts := len(steamPromptRegex.FindAllStringIndex(text, -1))
+	for ; s.prompt < prompts; s.prompt++ {
+		s.send()
+	}
+	s.guard.onPartial(text)
+}
+
+func (s *session) send() {
+	command := "quit"
+	if s.next < len(s.commands) {
+		command = s.commands[s.next]
+		s.next++
+	}
+	_, _ = io.WriteString(s.stdin, command+"\n")
+}
+
+type sessionSpec struct {
+	// installDir is passed as +force_install_dir when set.
+	installDir string
+	platform   Platform
+	account    *Account
+	// commands run after the login, which runSession issues itself.
+	commands []string
+	// secrets besides the account's that must not reach the logs.
+	secrets []string
+	// onLine receives stdout lines with any "Steam>" prompt removed.
+	onLine func(line string)
+}
+
+// runSession starts an interactive steamcmd, logs in and runs spec.commands.
+// abort must cancel ctx; it is used to end the session when a Steam Guard
+// prompt cannot be answered, with a cause wrapping ErrLoginFailed.
+func (s *SteamCMD) runSession(ctx context.Context, abort context.CancelCauseFunc, spec sessionSpec, cachedLogin bool) error {
+	login, err := loginCommand(spec.account, cachedLogin)
+	if err != nil {
+		return &DownloadError{Reason: err.Error(), Err: ErrLoginFailed}
+	}
+
+	// Only settings that must precede the login are passed as arguments;
+	// everything else, including credentials, goes over stdin.
+	args := []string{"+@NoPromptForPassword", "1"}
+	if spec.platform != "" {
+		args = append(args, "+@sSteamCmdForcePlatformType", string(spec.platform))
+	}
+	if spec.installDir != "" {
+		args = append(args, "+force_install_dir", spec.installDir)
+	}
+
+	cmd := s.command(ctx, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open steamcmd stdin: %w", err)
+	}
+	sess := &session{
+		stdin:    stdin,
+		commands: append([]string{login}, spec.commands...),
+		guard:    &guardResponder{ctx: ctx, account: spec.account, broker: s.Guard, stdin: stdin, abort: abort},
+	}
+
+	cmd.Stdout = &util.LineWriter{
+		OnLine: func(line string) {
+			sess.onLine(line)
+			spec.onLine(strings.TrimSpace(steamPromptRegex.ReplaceAllString(line, "")))
+		},
+		OnPartial: sess.onPartial,
+	}
+
+	secrets := append(secretsOf(spec.account), spec.secrets...)
+	cmd.Stderr = &util.LineWriter{OnLine: func(line string) {
+		fmt.Fprintln(os.Stderr, redact(line, secrets))
+	}}
+
+	return cmd.Run()
+}
+
+// withCachedLogin runs attempt with steamcmd's cached credentials when it
+// holds them for account, and again with the password if they are rejected.
+// attempt reports a rejected login by returning an error wrapping
+// ErrLoginFailed.
+func (s *SteamCMD) withCachedLogin(account *Account, attempt func(cached bool) error) error {
+	if account == nil {
+		return attempt(false)
+	}
+
+	cached := s.sessions.has(account.Username)
+
+	err := attempt(cached)
+	if cached && errors.Is(err, ErrLoginFailed) {
+		log.Printf("🔑 Cached steamcmd login for %s was rejected, logging in with the password.", account.Username)
+		s.sessions.set(account.Username, false)
+		err = attempt(false)
+	}
+
+	if !errors.Is(err, ErrLoginFailed) {
+		s.sessions.set(account.Username, true)
+	}
+
+	return err
+}
+
+func loginCommand(account *Account, cached bool) (string, error) {
+	if account == nil {
+		return "login anonymous", nil
+	}
+	if err := account.Validate(); err != nil {
+		return "", err
+	}
+	if cached {
+		return "login " + account.Username, nil
+	}
+
+	command := fmt.Sprintf("login %s %s", account.Username, account.Password)
+	if account.SharedSecret != "" {
+		code, err := GenerateGuardCode(account.SharedSecret, time.Now())
+		if err != nil {
+			return "", err
+		}
+		command += " " + code
+	}
+	return command, nil
+}
+
+// sessionCache tracks which accounts steamcmd holds cached credentials for,
+// so the password only has to be sent on the first login.
+type sessionCache struct {
+	configPath string
+
+	mu    sync.Mutex
+	known map[string]bool
+}
+
+func newSessionCache(installPath string) *sessionCache {
+	return &sessionCache{
+		configPath: filepath.Join(installPath, "config", "config.vdf"),
+		known:      make(map[string]bool),
+	}
+}
+
+func (c *sessionCache) has(username string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.known[username]; ok {
+		return cached
+	}
+
+	data, err := os.ReadFile(c.configPath)
+	cached := err == nil && strings.Contains(strings.ToLower(string(data)), `"`+strings.ToLower(username)+`"`)
+	c.known[username] = cached
+	return cached
+}
+
+func (c *sessionCache) set(username string, cached bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.known[username] = cached
+}
+
+func secretsOf(account *Account) []string {
+	if account == nil {
+		return nil
+	}
+	return []string{account.Password, account.SharedSecret}
+}
+
+// Redact masks the account's password and shared secret in output relayed
+// from a download tool.
+func Redact(text string, account *Account) string {
+	return redact(text, secretsOf(account))
+}
+
+func redact(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, "********")
+		}
+	}
+	return text
+}
@@ -0,0 +1,50 @@
+package steamcmd
+
+import "testing"
+
+func TestLoginCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		account *Account
+		cached  bool
+		want    string
+		wantErr bool
+	}{
+		{"anonymous", nil, false, "login anonymous", false},
+		{"password", &Account{Username: "bob", Password: "hunter2"}, false, "login bob hunter2", false},
+		{"cached", &Account{Username: "bob", Password: "hunter2"}, true, "login bob", false},
+		{"password with space", &Account{Username: "bob", Password: "two words"}, false, "", true},
+		{"password with newline", &Account{Username: "bob", Password: "x\nquit"}, false, "", true},
+		{"username with newline", &Account{Username: "bob\nquit", Password: "x"}, true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loginCommand(tt.account, tt.cached)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loginCommand = %q, %v, want error: %t", got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("loginCommand = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	account := &Account{Username: "bob", Password: "hunter2", SharedSecret: "c2VjcmV0"}
+	tests := []struct {
+		line    string
+		account *Account
+		want    string
+	}{
+		{"Logging in 'bob' with hunter2", account, "Logging in 'bob' with ********"},
+		{"secret c2VjcmV0", account, "secret ********"},
+		{"nothing to hide", account, "nothing to hide"},
+		{"anonymous hunter2", nil, "anonymous hunter2"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.line, tt.account); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package steamcmd
 
 import (
+	"context"
 	"fmt"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/retry"
 	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
-	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,11 +17,19 @@ import (
 type SteamCMD struct {
 	InstallPath string
 	ExePath     string
-	username    string
-	password    string
+	// ContentRoot, when set, is passed as +force_install_dir so downloads and
+	// steamapps state live outside InstallPath.
+	ContentRoot string
+	RetryPolicy retry.Policy
+	// Guard supplies Steam Guard codes for accounts without a shared secret.
+	Guard *GuardBroker
+	// HTTPClient downloads the installer; it defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	sessions *sessionCache
 }
 
-func New(installPath string, username string, password string) (*SteamCMD, error) {
+func New(installPath string) (*SteamCMD, error) {
 	exeName := "steamcmd"
 	if runtime.GOOS == "windows" {
 		exeName += ".exe"
@@ -35,12 +45,12 @@ func New(installPath string, username string, password string) (*SteamCMD, error
 	return &SteamCMD{
 		InstallPath: installPath,
 		ExePath:     absExePath,
-		username:    username,
-		password:    password,
+		RetryPolicy: retry.DefaultPolicy(),
+		sessions:    newSessionCache(installPath),
 	}, nil
 }
 
-func (s *SteamCMD) Install() error {
+func (s *SteamCMD) Install(ctx context.Context) error {
 	if _, err := os.Stat(s.ExePath); err == nil {
 		log.Println("✅ SteamCMD is already installed.")
 		return nil
@@ -48,17 +58,24 @@ func (s *SteamCMD) Install() error {
 
 	log.Println("Installing SteamCMD...")
 	if runtime.GOOS == "windows" {
-		return s.installWindows()
+		return s.installWindows(ctx)
 	}
-	return s.installLinux()
+	return s.installLinux(ctx)
 }
 
-func (s *SteamCMD) installWindows() error {
+func (s *SteamCMD) httpClient() *http.Client {
+	if s.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return s.HTTPClient
+}
+
+func (s *SteamCMD) installWindows(ctx context.Context) error {
 	url := "https://steamcdn-a.akamaihd.net/client/installer/steamcmd.zip"
 	zipPath := filepath.Join(s.InstallPath, "steamcmd.zip")
 	defer os.Remove(zipPath)
 
-	if err := util.DownloadFile(url, zipPath); err != nil {
+	if err := util.DownloadFile(ctx, s.httpClient(), url, zipPath); err != nil {
 		return fmt.Errorf("failed to download steamcmd for Windows: %w", err)
 	}
 
@@ -66,16 +83,16 @@ func (s *SteamCMD) installWindows() error {
 		return fmt.Errorf("failed to unzip steamcmd: %w", err)
 	}
 
-	return s.finalizeInstallation()
+	return s.finalizeInstallation(ctx)
 }
 
-func (s *SteamCMD) installLinux() error {
+func (s *SteamCMD) installLinux(ctx context.Context) error {
 
 	url := "https://steamcdn-a.akamaihd.net/client/installer/steamcmd_linux.tar.gz"
 	tarPath := filepath.Join(s.InstallPath, "steamcmd.tar.gz")
 	defer os.Remove(tarPath)
 
-	if err := util.DownloadFile(url, tarPath); err != nil {
+	if err := util.DownloadFile(ctx, s.httpClient(), url, tarPath); err != nil {
 		return fmt.Errorf("failed to download steamcmd for Linux: %w", err)
 	}
 
@@ -87,13 +104,12 @@ func (s *SteamCMD) installLinux() error {
 		return fmt.Errorf("failed to make steamcmd executable: %w", err)
 	}
 
-	return s.finalizeInstallation()
+	return s.finalizeInstallation(ctx)
 }
 
-func (s *SteamCMD) finalizeInstallation() error {
+func (s *SteamCMD) finalizeInstallation(ctx context.Context) error {
 	log.Println("💦 Finalizing SteamCMD installation (this may take a moment)...")
-	cmd := exec.Command(s.ExePath, "+quit")
-	cmd.Dir = s.InstallPath
+	cmd := s.command(ctx, "+quit")
 	if err := cmd.Run(); err != nil {
 
 		log.Printf("⚠️ SteamCMD quit with a non-zero exit code during finalization, this is often normal: %v", err)
@@ -102,38 +118,32 @@ func (s *SteamCMD) finalizeInstallation() error {
 	return nil
 }
 
-func (s *SteamCMD) DownloadWorkshopItem(appID, workshopID int, validate bool) error {
-	loginInfo := []string{"+login", "anonymous"}
-
-	if s.username != "" {
-		loginInfo = append(loginInfo, "+login", s.username, s.password)
-	}
-
-	args := append(
-		loginInfo,
-		[]string{"+workshop_download_item", fmt.Sprint(appID), fmt.Sprint(workshopID)}...,
-	)
-
-	if validate {
-		args = append(args, "validate")
-	}
-	args = append(args, "+quit")
-
-	cmd := exec.Command(s.ExePath, args...)
+// command prepares a steamcmd invocation that is killed, together with any
+// children, when ctx is done.
+func (s *SteamCMD) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, s.ExePath, args...)
 	cmd.Dir = s.InstallPath
-	cmd.Stdout = io.Discard
-	cmd.Stderr = os.Stderr
+	cmd.WaitDelay = 5 * time.Second
+	setProcessGroup(cmd)
+	return cmd
+}
 
-	if err := cmd.Run(); err != nil {
-		time.Sleep(2 * time.Second)
-		if errRetry := cmd.Run(); errRetry != nil {
-			return fmt.Errorf("steamcmd execution failed after retry: %w", errRetry)
-		}
+func (s *SteamCMD) contentRoot() string {
+	if s.ContentRoot != "" {
+		return s.ContentRoot
 	}
+	return s.InstallPath
+}
 
-	return nil
+// workshopRoot is the install dir workshop content is downloaded into.
+// Content for a forced platform is kept apart from the host's.
+func (s *SteamCMD) workshopRoot(platform Platform) string {
+	if platform == "" {
+		return s.contentRoot()
+	}
+	return filepath.Join(s.contentRoot(), "platforms", string(platform))
 }
 
-func (s *SteamCMD) GetWorkshopContentPath(appID, workshopID int) string {
-	return filepath.Join(s.InstallPath, "steamapps", "workshop", "content", fmt.Sprint(appID), fmt.Sprint(workshopID))
+func (s *SteamCMD) GetWorkshopContentPath(appID, workshopID int, platform Platform) string {
+	return filepath.Join(s.workshopRoot(platform), "steamapps", "workshop", "content", fmt.Sprint(appID), fmt.Sprint(workshopID))
 }
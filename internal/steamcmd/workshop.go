@@ -0,0 +1,226 @@
+package steamcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/retry"
+)
+
+type DownloadOptions struct {
+	Validate bool
+	Platform Platform
+	// ItemTimeout bounds how long a single item may take within a session.
+	// Zero disables the per-item deadline.
+	ItemTimeout time.Duration
+	// Account to log in with; nil logs in anonymously.
+	Account    *Account
+	OnStart    func(workshopID int)
+	OnProgress ProgressFunc
+	// OnResult is called once per item with its final outcome, as soon as it
+	// is known. Successes are reported while the session is still running.
+	OnResult func(ItemResult)
+}
+
+type ItemResult struct {
+	WorkshopID int
+	Err        error
+}
+
+func (s *SteamCMD) DownloadWorkshopItem(ctx context.Context, appID, workshopID int, opts DownloadOptions) error {
+	return s.DownloadWorkshopItems(ctx, appID, []int{workshopID}, opts)[0].Err
+}
+
+// DownloadWorkshopItems downloads every item in a single steamcmd session,
+// so the login handshake is paid once rather than once per item. Items that
+// fail transiently are retried together according to s.RetryPolicy.
+func (s *SteamCMD) DownloadWorkshopItems(ctx context.Context, appID int, workshopIDs []int, opts DownloadOptions) []ItemResult {
+	final := make(map[int]error, len(workshopIDs))
+	pending := uniqueIDs(workshopIDs)
+
+	installDir := s.ContentRoot
+	if opts.Platform != "" {
+		dir, err := filepath.Abs(s.workshopRoot(opts.Platform))
+		if err == nil {
+			err = os.MkdirAll(dir, 0755)
+		}
+		if err != nil {
+			return failAll(workshopIDs, fmt.Errorf("failed to create workshop directory for %s: %w", opts.Platform, err), opts)
+		}
+		installDir = dir
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		outcomes := s.runWorkshopBatch(ctx, appID, installDir, pending, opts)
+
+		var retrying []int
+		for _, id := range pending {
+			err := outcomes[id]
+			if err == nil {
+				final[id] = nil
+				continue
+			}
+
+			if attempt >= s.RetryPolicy.MaxAttempts || !IsTransient(err) {
+				if attempt > 1 {
+					err = fmt.Errorf("steamcmd failed after %d attempts: %w", attempt, err)
+				}
+				final[id] = err
+				if opts.OnResult != nil {
+					opts.OnResult(ItemResult{WorkshopID: id, Err: err})
+				}
+				continue
+			}
+
+			retrying = append(retrying, id)
+		}
+
+		if len(retrying) > 0 {
+			delay := s.RetryPolicy.Delay(attempt)
+			log.Printf("⚠️ steamcmd attempt %d/%d failed for %d item(s) of app %d (retrying in %s)", attempt, s.RetryPolicy.MaxAttempts, len(retrying), appID, delay.Round(time.Millisecond))
+
+			if err := retry.Sleep(ctx, delay); err != nil {
+				err = fmt.Errorf("steamcmd cancelled: %w", err)
+				for _, id := range retrying {
+					final[id] = err
+					if opts.OnResult != nil {
+						opts.OnResult(ItemResult{WorkshopID: id, Err: err})
+					}
+				}
+				retrying = nil
+			}
+		}
+		pending = retrying
+	}
+
+	results := make([]ItemResult, len(workshopIDs))
+	for i, id := range workshopIDs {
+		results[i] = ItemResult{WorkshopID: id, Err: final[id]}
+	}
+	return results
+}
+
+func (s *SteamCMD) runWorkshopBatch(ctx context.Context, appID int, installDir string, workshopIDs []int, opts DownloadOptions) map[int]error {
+	var outcomes map[int]error
+	s.withCachedLogin(opts.Account, func(cached bool) error {
+		outcomes = s.runWorkshopSession(ctx, appID, installDir, workshopIDs, opts, cached)
+		if loginFailed(outcomes) {
+			return ErrLoginFailed
+		}
+		return nil
+	})
+	return outcomes
+}
+
+func (s *SteamCMD) runWorkshopSession(ctx context.Context, appID int, installDir string, workshopIDs []int, opts DownloadOptions, cachedLogin bool) map[int]error {
+	var commands []string
+	for _, id := range workshopIDs {
+		command := fmt.Sprintf("workshop_download_item %d %d", appID, id)
+		if opts.Validate {
+			command += " validate"
+		}
+		commands = append(commands, command)
+	}
+
+	sessionCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// The watchdog is re-armed whenever an item starts or finishes, so it
+	// only fires when a single item exceeds opts.ItemTimeout.
+	rearm := func() {}
+	if opts.ItemTimeout > 0 {
+		watchdog := time.AfterFunc(opts.ItemTimeout, func() { cancel(ErrTimeout) })
+		defer watchdog.Stop()
+		rearm = func() { watchdog.Reset(opts.ItemTimeout) }
+	}
+
+	parser := newOutputParser()
+	parser.onStart = func(id int) {
+		rearm()
+		if opts.OnStart != nil {
+			opts.OnStart(id)
+		}
+	}
+	parser.onSuccess = func(id int) {
+		rearm()
+		if opts.OnResult != nil {
+			opts.OnResult(ItemResult{WorkshopID: id})
+		}
+	}
+
+	runErr := s.runSession(sessionCtx, cancel, sessionSpec{
+		installDir: installDir,
+		platform:   opts.Platform,
+		account:    opts.Account,
+		commands:   commands,
+		onLine: func(line string) {
+			parser.parseLine(line)
+			if opts.OnProgress == nil || parser.current == 0 {
+				return
+			}
+			if percent, ok := parseProgress(line); ok {
+				opts.OnProgress(parser.current, percent)
+			}
+		},
+	}, cachedLogin)
+
+	cause := context.Cause(sessionCtx)
+	if ctx.Err() != nil {
+		runErr = fmt.Errorf("steamcmd cancelled: %w", context.Cause(ctx))
+	} else if errors.Is(runErr, ErrLoginFailed) {
+		parser.loginErr = runErr
+	} else if errors.Is(cause, ErrLoginFailed) {
+		parser.loginErr = cause
+	} else if cause == ErrTimeout {
+		if id := parser.current; id != 0 && !parser.succeeded[id] {
+			parser.itemErrors[id] = &DownloadError{
+				WorkshopID: id,
+				Reason:     fmt.Sprintf("no result within %s", opts.ItemTimeout),
+				Err:        ErrTimeout,
+			}
+		}
+		runErr = fmt.Errorf("steamcmd session aborted: %w", ErrTimeout)
+	}
+
+	// Session-level errors only apply to items steamcmd reported nothing for.
+	outcomes := make(map[int]error, len(workshopIDs))
+	for _, id := range workshopIDs {
+		err := parser.itemErr(id)
+		if !parser.hasOutcome(id) {
+			if ctx.Err() != nil {
+				err = runErr
+			} else if runErr != nil && parser.loginErr == nil && parser.otherErr == nil {
+				err = fmt.Errorf("steamcmd execution failed: %w", runErr)
+			}
+		}
+		outcomes[id] = err
+	}
+
+	return outcomes
+}
+
+func loginFailed(outcomes map[int]error) bool {
+	for _, err := range outcomes {
+		if errors.Is(err, ErrLoginFailed) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
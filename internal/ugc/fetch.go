@@ -0,0 +1,123 @@
+package ugc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+var (
+	errSizeMismatch = errors.New("downloaded size does not match the published file size")
+	errStatus       = errors.New("unexpected status")
+)
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("%v %d", errStatus, e.code) }
+
+// fetch downloads url into part, resuming from what an earlier attempt left
+// behind. A part that does not end up exactly size bytes long is discarded.
+func fetch(ctx context.Context, client *http.Client, url, part string, size int64, onProgress func(float64)) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+	if offset > size {
+		os.Remove(part)
+		offset = 0
+	}
+
+	if offset < size {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		switch {
+		case res.StatusCode == http.StatusPartialContent && offset > 0:
+		case res.StatusCode == http.StatusOK:
+			// The server ignored the range, start over.
+			flags |= os.O_TRUNC
+			offset = 0
+		default:
+			return &statusError{code: res.StatusCode}
+		}
+
+		out, err := os.OpenFile(part, flags, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, &progressReader{r: res.Body, read: offset, size: size, onProgress: onProgress})
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(part)
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		os.Remove(part)
+		return fmt.Errorf("%w: got %d bytes, expected %d", errSizeMismatch, info.Size(), size)
+	}
+	return nil
+}
+
+// classify maps a fetch error onto the steamcmd errors, so retries and API
+// responses treat both backends alike.
+func classify(err error) error {
+	var status *statusError
+	if errors.As(err, &status) {
+		switch {
+		case status.code == http.StatusNotFound || status.code == http.StatusGone:
+			return steamcmd.ErrItemNotFound
+		case status.code == http.StatusForbidden || status.code == http.StatusUnauthorized:
+			return steamcmd.ErrAccessDenied
+		case status.code == http.StatusTooManyRequests:
+			return steamcmd.ErrRateLimited
+		}
+	}
+	return steamcmd.ErrDownloadFailed
+}
+
+type progressReader struct {
+	r          io.Reader
+	read       int64
+	size       int64
+	reported   int
+	onProgress func(float64)
+}
+
+// Read reports progress in whole percent steps.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.onProgress != nil && p.size > 0 {
+		if percent := int(p.read * 100 / p.size); percent > p.reported {
+			p.reported = percent
+			p.onProgress(float64(percent))
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,155 @@
+package ugc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+// WorkshopApps adds the apps with directly downloaded items to the
+// fallback's.
+func (d *Downloader) WorkshopApps() ([]int, error) {
+	entries, err := os.ReadDir(d.ContentRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read UGC content directory: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	for _, entry := range entries {
+		if appID, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			seen[appID] = true
+		}
+	}
+	if inv, ok := d.Fallback.(downloader.Inventory); ok {
+		apps, err := inv.WorkshopApps()
+		if err != nil {
+			return nil, err
+		}
+		for _, appID := range apps {
+			seen[appID] = true
+		}
+	}
+
+	apps := make([]int, 0, len(seen))
+	for appID := range seen {
+		apps = append(apps, appID)
+	}
+	sort.Ints(apps)
+	return apps, nil
+}
+
+// InstalledWorkshopItems merges the directly downloaded items with the
+// fallback's, keeping the most recently updated copy of each.
+func (d *Downloader) InstalledWorkshopItems(appID int) ([]steamcmd.InstalledItem, error) {
+	own, err := d.installed(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, ok := d.Fallback.(downloader.Inventory)
+	if !ok {
+		return downloader.MergeInstalled(own), nil
+	}
+	items, err := inv.InstalledWorkshopItems(appID)
+	if err != nil {
+		return nil, err
+	}
+	return downloader.MergeInstalled(own, items), nil
+}
+
+// InstalledWorkshopItem prefers the direct copy, which serves every
+// platform, unless the fallback holds a more recent one.
+func (d *Downloader) InstalledWorkshopItem(appID, workshopID int, platform steamcmd.Platform) (steamcmd.InstalledItem, bool, error) {
+	var (
+		fallback steamcmd.InstalledItem
+		found    bool
+	)
+	if inv, ok := d.Fallback.(downloader.Inventory); ok {
+		var err error
+		if fallback, found, err = inv.InstalledWorkshopItem(appID, workshopID, platform); err != nil {
+			return steamcmd.InstalledItem{}, false, err
+		}
+	}
+	if item, ok := d.installedItem(appID, workshopID); ok && (!found || !fallback.TimeUpdated.After(item.TimeUpdated)) {
+		return item, true, nil
+	}
+	return fallback, found, nil
+}
+
+func (d *Downloader) RemoveWorkshopItem(appID, workshopID int) (bool, error) {
+	removed := false
+	dir := d.itemDir(appID, workshopID)
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.RemoveAll(dir); err != nil {
+			return false, fmt.Errorf("failed to remove workshop item %d: %w", workshopID, err)
+		}
+		removed = true
+	}
+	d.removeStaleParts(appID, workshopID, "")
+
+	if inv, ok := d.Fallback.(downloader.Inventory); ok {
+		fallbackRemoved, err := inv.RemoveWorkshopItem(appID, workshopID)
+		return removed || fallbackRemoved, err
+	}
+	return removed, nil
+}
+
+// installed lists the directly downloaded items of an app. Their files carry
+// the item's time_updated as modification time.
+func (d *Downloader) installed(appID int) ([]steamcmd.InstalledItem, error) {
+	entries, err := os.ReadDir(filepath.Join(d.ContentRoot, fmt.Sprint(appID)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read UGC content directory: %w", err)
+	}
+
+	var items []steamcmd.InstalledItem
+	for _, entry := range entries {
+		id, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		if item, ok := d.installedItem(appID, id); ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (d *Downloader) installedItem(appID, workshopID int) (steamcmd.InstalledItem, bool) {
+	dir := d.itemDir(appID, workshopID)
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) == 0 {
+		return steamcmd.InstalledItem{}, false
+	}
+	info, err := files[0].Info()
+	if err != nil {
+		return steamcmd.InstalledItem{}, false
+	}
+
+	return steamcmd.InstalledItem{
+		WorkshopID:  workshopID,
+		Size:        info.Size(),
+		TimeUpdated: info.ModTime().UTC(),
+		Path:        dir,
+	}, true
+}
+
+// fallbackIsNewer reports whether the fallback holds a copy of the item that
+// was updated after the direct download.
+func (d *Downloader) fallbackIsNewer(appID int, direct steamcmd.InstalledItem, platform steamcmd.Platform) bool {
+	inv, ok := d.Fallback.(downloader.Inventory)
+	if !ok {
+		return false
+	}
+	item, ok, err := inv.InstalledWorkshopItem(appID, direct.WorkshopID, platform)
+	return err == nil && ok && item.TimeUpdated.After(direct.TimeUpdated)
+}
@@ -0,0 +1,294 @@
+// Package ugc downloads legacy workshop items straight from the file_url
+// Steam reports for them on Valve's UGC CDN, which is faster than steamcmd
+// and works for items steamcmd refuses to fetch anonymously.
+package ugc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/retry"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/util"
+)
+
+// Downloader fetches items that have a file_url over HTTP and hands every
+// other item, and every item whose direct download failed, to Fallback.
+type Downloader struct {
+	ContentRoot string
+	Steam       *steam.Client
+	Fallback    downloader.Downloader
+	RetryPolicy retry.Policy
+	// HTTPClient should not set a Timeout, which would cap the size of the
+	// files that can be downloaded.
+	HTTPClient *http.Client
+
+	items sync.Map
+}
+
+// itemKey identifies an item whose part file and content directory are
+// shared by every download of it.
+type itemKey struct {
+	appID      int
+	workshopID int
+}
+
+func New(contentRoot string, client *steam.Client, fallback downloader.Downloader) (*Downloader, error) {
+	root, err := filepath.Abs(contentRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve UGC content directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, partialDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create UGC content directory: %w", err)
+	}
+
+	return &Downloader{
+		ContentRoot: root,
+		Steam:       client,
+		Fallback:    fallback,
+		RetryPolicy: retry.DefaultPolicy(),
+		HTTPClient:  &http.Client{},
+	}, nil
+}
+
+// partialDir holds unfinished downloads, outside of any item's content.
+const partialDir = ".partial"
+
+func (d *Downloader) Size() int {
+	return d.Fallback.Size()
+}
+
+func (d *Downloader) Health(ctx context.Context) error {
+	return errors.Join(d.health(), d.Fallback.Health(ctx))
+}
+
+func (d *Downloader) health() error {
+	if _, err := os.Stat(d.ContentRoot); err != nil {
+		return fmt.Errorf("UGC content directory is missing: %w", err)
+	}
+	return nil
+}
+
+// Statuses adds the direct downloader to the fallback's backends.
+func (d *Downloader) Statuses(ctx context.Context) map[string]error {
+	statuses := map[string]error{}
+	if r, ok := d.Fallback.(interface {
+		Statuses(ctx context.Context) map[string]error
+	}); ok {
+		statuses = r.Statuses(ctx)
+	} else {
+		statuses["fallback"] = d.Fallback.Health(ctx)
+	}
+	statuses["ugc"] = d.health()
+	return statuses
+}
+
+// GetWorkshopContentPath returns the directly downloaded copy unless the
+// fallback holds a more recent one. A file_url serves the same file to every
+// platform.
+func (d *Downloader) GetWorkshopContentPath(appID, workshopID int, platform steamcmd.Platform) string {
+	if item, ok := d.installedItem(appID, workshopID); ok && !d.fallbackIsNewer(appID, item, platform) {
+		return item.Path
+	}
+	return d.Fallback.GetWorkshopContentPath(appID, workshopID, platform)
+}
+
+// DownloadWorkshopManifest leaves historical builds to the fallback, since a
+// file_url only ever points at the current one.
+func (d *Downloader) DownloadWorkshopManifest(ctx context.Context, appID, workshopID int, opts steamcmd.DepotOptions) (string, error) {
+	md, ok := d.Fallback.(downloader.ManifestDownloader)
+	if !ok {
+		return "", downloader.ErrManifestUnsupported
+	}
+	return md.DownloadWorkshopManifest(ctx, appID, workshopID, opts)
+}
+
+func (d *Downloader) itemDir(appID, workshopID int) string {
+	return filepath.Join(d.ContentRoot, fmt.Sprint(appID), fmt.Sprint(workshopID))
+}
+
+func (d *Downloader) DownloadWorkshopItem(ctx context.Context, appID, workshopID int, opts steamcmd.DownloadOptions) error {
+	return d.DownloadWorkshopItems(ctx, appID, []int{workshopID}, opts)[0].Err
+}
+
+// DownloadWorkshopItems downloads the items with a file_url one after another
+// while the fallback works through the rest.
+func (d *Downloader) DownloadWorkshopItems(ctx context.Context, appID int, workshopIDs []int, opts steamcmd.DownloadOptions) []steamcmd.ItemResult {
+	items, err := d.Steam.GetWorkshopItems(ctx, workshopIDs)
+	if err != nil {
+		log.Printf("⚠️ Could not look up file URLs, downloading %d items with the fallback backend: %v", len(workshopIDs), err)
+		items = make([]*steam.WorkshopItem, len(workshopIDs))
+	}
+
+	var (
+		mu    sync.Mutex
+		final = make(map[int]error, len(workshopIDs))
+	)
+	record := func(results []steamcmd.ItemResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range results {
+			final[r.WorkshopID] = r.Err
+		}
+	}
+
+	var direct []*steam.WorkshopItem
+	var rest []int
+	for i, id := range workshopIDs {
+		if item := items[i]; item != nil && item.FileURL != "" && item.FileSize > 0 {
+			direct = append(direct, item)
+		} else {
+			rest = append(rest, id)
+		}
+	}
+
+	var wg sync.WaitGroup
+	fallback := func(ids []int) {
+		defer wg.Done()
+		results := d.Fallback.DownloadWorkshopItems(ctx, appID, ids, opts)
+		for _, r := range results {
+			// A stale direct copy would otherwise shadow the fresh one.
+			if r.Err == nil {
+				os.RemoveAll(d.itemDir(appID, r.WorkshopID))
+			}
+		}
+		record(results)
+	}
+
+	if len(rest) > 0 {
+		wg.Add(1)
+		go fallback(rest)
+	}
+
+	var failed []int
+	for _, item := range direct {
+		if opts.OnStart != nil {
+			opts.OnStart(item.ID)
+		}
+
+		err := d.download(ctx, appID, item, opts)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Direct download of item %d failed, falling back: %v", item.ID, err)
+			failed = append(failed, item.ID)
+			continue
+		}
+
+		record([]steamcmd.ItemResult{{WorkshopID: item.ID, Err: err}})
+		if opts.OnResult != nil {
+			opts.OnResult(steamcmd.ItemResult{WorkshopID: item.ID, Err: err})
+		}
+	}
+
+	if len(failed) > 0 {
+		wg.Add(1)
+		go fallback(failed)
+	}
+	wg.Wait()
+
+	results := make([]steamcmd.ItemResult, len(workshopIDs))
+	for i, id := range workshopIDs {
+		results[i] = steamcmd.ItemResult{WorkshopID: id, Err: final[id]}
+	}
+	return results
+}
+
+func (d *Downloader) download(ctx context.Context, appID int, item *steam.WorkshopItem, opts steamcmd.DownloadOptions) error {
+	// Concurrent downloads of the same item would append to one part file
+	// and remove each other's stale parts.
+	lock, _ := d.items.LoadOrStore(itemKey{appID: appID, workshopID: item.ID}, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	dir := d.itemDir(appID, item.ID)
+	target := filepath.Join(dir, fileName(item))
+
+	if upToDate(target, item) {
+		log.Printf("ℹ️ Item %d is already downloaded from its file URL", item.ID)
+		return nil
+	}
+
+	version := int64(0)
+	if item.Updated != nil {
+		version = item.Updated.Unix()
+	}
+	part := filepath.Join(d.ContentRoot, partialDir, fmt.Sprintf("%d_%d_%d.part", appID, item.ID, version))
+	d.removeStaleParts(appID, item.ID, part)
+
+	log.Printf("⬇️ Downloading item %d directly from %s", item.ID, item.FileURL)
+
+	var onProgress func(float64)
+	if opts.OnProgress != nil {
+		onProgress = func(percent float64) { opts.OnProgress(item.ID, percent) }
+	}
+
+	_, err := d.RetryPolicy.Do(ctx, steamcmd.IsTransient, func(attempt int, err error, delay time.Duration) {
+		log.Printf("⚠️ Direct download attempt %d/%d failed for item %d: %v (retrying in %s)", attempt, d.RetryPolicy.MaxAttempts, item.ID, err, delay.Round(time.Millisecond))
+	}, func() error {
+		if err := fetch(ctx, d.HTTPClient, item.FileURL, part, item.FileSize, onProgress); err != nil {
+			return &steamcmd.DownloadError{WorkshopID: item.ID, Reason: err.Error(), Err: classify(err)}
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("direct download cancelled: %w", context.Cause(ctx))
+	}
+	if err != nil {
+		return err
+	}
+
+	// Replace any older version only once the new one is complete.
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove old content of item %d: %w", item.ID, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create content directory for item %d: %w", item.ID, err)
+	}
+	if err := os.Rename(part, target); err != nil {
+		return fmt.Errorf("failed to move item %d into place: %w", item.ID, err)
+	}
+	if item.Updated != nil {
+		os.Chtimes(target, *item.Updated, *item.Updated)
+	}
+
+	log.Printf("✅ Downloaded item %d directly (%d bytes)", item.ID, item.FileSize)
+	return nil
+}
+
+// upToDate reports whether target holds the current version of item. The
+// file's modification time is set to the item's update time on completion.
+func upToDate(target string, item *steam.WorkshopItem) bool {
+	info, err := os.Stat(target)
+	if err != nil || info.Size() != item.FileSize {
+		return false
+	}
+	return item.Updated == nil || info.ModTime().Equal(*item.Updated)
+}
+
+func (d *Downloader) removeStaleParts(appID, workshopID int, keep string) {
+	matches, _ := filepath.Glob(filepath.Join(d.ContentRoot, partialDir, fmt.Sprintf("%d_%d_*.part", appID, workshopID)))
+	for _, match := range matches {
+		if match != keep {
+			os.Remove(match)
+		}
+	}
+}
+
+// fileName keeps the name the file was uploaded under, without any
+// directories Steam reports for it.
+func fileName(item *steam.WorkshopItem) string {
+	if name := util.SanitizeFileName(path.Base(strings.ReplaceAll(item.FileName, `\`, "/"))); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%d.bin", item.ID)
+}
@@ -0,0 +1,130 @@
+package ugc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/downloader"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steam"
+	"github.com/SyNdicateFoundation/SteamDownloaderAPI/internal/steamcmd"
+)
+
+func TestConcurrentDownloadsOfOneItem(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// Slow enough for the downloads to overlap without the lock.
+		for start := 0; start < len(content); start += 10000 {
+			w.Write(content[start : start+10000])
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	d, err := New(t.TempDir(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := time.Unix(1700000000, 0)
+	item := &steam.WorkshopItem{ID: 1, FileName: "mod.gma", FileURL: server.URL, FileSize: int64(len(content)), Updated: &updated}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.download(context.Background(), 4000, item, steamcmd.DownloadOptions{})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("download %d: %v", i, err)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(d.itemDir(4000, 1), "mod.gma"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes that differ from the %d served", len(got), len(content))
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("item fetched %d times, want once", n)
+	}
+}
+
+// fakeFallback holds one installed item and fails any full listing.
+type fakeFallback struct {
+	downloader.Downloader
+	item steamcmd.InstalledItem
+}
+
+func (f *fakeFallback) GetWorkshopContentPath(appID, workshopID int, platform steamcmd.Platform) string {
+	return f.item.Path
+}
+
+func (f *fakeFallback) WorkshopApps() ([]int, error) { return nil, nil }
+
+func (f *fakeFallback) InstalledWorkshopItems(appID int) ([]steamcmd.InstalledItem, error) {
+	return nil, errors.New("listed the whole app")
+}
+
+func (f *fakeFallback) InstalledWorkshopItem(appID, workshopID int, platform steamcmd.Platform) (steamcmd.InstalledItem, bool, error) {
+	return f.item, workshopID == f.item.WorkshopID && platform == f.item.Platform, nil
+}
+
+func (f *fakeFallback) RemoveWorkshopItem(appID, workshopID int) (bool, error) { return false, nil }
+
+func TestGetWorkshopContentPath(t *testing.T) {
+	direct := time.Unix(1700000000, 0)
+	tests := []struct {
+		name       string
+		fallback   time.Time
+		platform   steamcmd.Platform
+		wantDirect bool
+	}{
+		{"direct copy is newer", direct.Add(-time.Hour), "", true},
+		{"same version", direct, "", true},
+		{"fallback is newer", direct.Add(time.Hour), "", false},
+		{"fallback is newer for another platform", direct.Add(time.Hour), steamcmd.PlatformWindows, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := &fakeFallback{item: steamcmd.InstalledItem{WorkshopID: 1, TimeUpdated: tt.fallback, Path: "/fallback/1"}}
+			d, err := New(t.TempDir(), nil, fallback)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := filepath.Join(d.itemDir(4000, 1), "mod.gma")
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(target, []byte("mod"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			os.Chtimes(target, direct, direct)
+
+			want := fallback.item.Path
+			if tt.wantDirect {
+				want = d.itemDir(4000, 1)
+			}
+			if got := d.GetWorkshopContentPath(4000, 1, tt.platform); got != want {
+				t.Errorf("GetWorkshopContentPath = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LineWriter splits what a child process writes into lines and hands each
+// complete line to OnLine. Both \n and \r count as separators, since
+// progress output is redrawn with \r. Interactive prompts are not newline
+// terminated, so any unterminated remainder is passed to OnPartial.
+type LineWriter struct {
+	OnLine    func(line string)
+	OnPartial func(text string)
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		data := w.buf.Bytes()
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimSpace(data[:i]))
+		w.buf.Next(i + 1)
+		if line != "" {
+			w.OnLine(line)
+		}
+	}
+
+	if w.OnPartial != nil && w.buf.Len() > 0 {
+		w.OnPartial(w.buf.String())
+	}
+
+	return len(p), nil
+}
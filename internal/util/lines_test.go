@@ -0,0 +1,41 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		lines    []string
+		partials []string
+	}{
+		{"newlines", []string{"a\nb\n"}, []string{"a", "b"}, nil},
+		{"carriage returns", []string{" 10%\r 20%\r\n"}, []string{"10%", "20%"}, nil},
+		{"split across writes", []string{"hel", "lo\n"}, []string{"hello"}, []string{"hel"}},
+		{"blank lines dropped", []string{"\n\n  \na\n"}, []string{"a"}, nil},
+		{"prompt", []string{"Loading\nSteam>"}, []string{"Loading"}, []string{"Steam>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines, partials []string
+			w := &LineWriter{
+				OnLine:    func(line string) { lines = append(lines, line) },
+				OnPartial: func(text string) { partials = append(partials, text) },
+			}
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %q, want %q", lines, tt.lines)
+			}
+			if !reflect.DeepEqual(partials, tt.partials) {
+				t.Errorf("partials = %q, want %q", partials, tt.partials)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -11,16 +12,21 @@ import (
 	"strings"
 )
 
-func DownloadFile(url, targetPath string) error {
+func DownloadFile(ctx context.Context, client *http.Client, url, targetPath string) error {
 	log.Printf("⬇️ Downloading from %s to %s", url, targetPath)
 
 	if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
 		return fmt.Errorf("failed to create directory for download: %w", err)
 	}
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("http.Get failed: %w", err)
+		return fmt.Errorf("invalid download request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -49,3 +55,22 @@ func SanitizeFileName(name string) string {
 
 	return strings.Trim(sanitized, " .")
 }
+
+// DirSize sums the size of every regular file below path.
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
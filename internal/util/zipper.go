@@ -17,25 +17,64 @@ type ZipSource struct {
 	Alias string
 }
 
-func ZipDirectory(sourcePath, targetZipPath string) error {
+type ProgressFunc func(bytesWritten int64)
+
+// excludedDirs hold downloader state kept next to the content, such as
+// DepotDownloader's manifests, which it needs to update the content later.
+var excludedDirs = map[string]bool{".DepotDownloader": true}
+
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+func ZipDirectory(sourcePath, targetZipPath string, onProgress ProgressFunc) error {
 	sources := []ZipSource{{
 		Path:  sourcePath,
 		Alias: filepath.Base(sourcePath),
 	}}
-	return ZipMultipleDirectories(sources, targetZipPath)
+	return ZipMultipleDirectories(sources, targetZipPath, onProgress)
 }
 
-func ZipMultipleDirectories(sources []ZipSource, targetZipPath string) error {
+// ZipMultipleDirectories writes the archive to a temporary file next to
+// targetZipPath and renames it into place once it is complete, so a file at
+// targetZipPath is never partial.
+func ZipMultipleDirectories(sources []ZipSource, targetZipPath string, onProgress ProgressFunc) error {
 	log.Printf("📦 Creating zip archive at %s", targetZipPath)
 
-	zipfile, err := os.Create(targetZipPath)
+	zipfile, err := os.CreateTemp(filepath.Dir(targetZipPath), "."+filepath.Base(targetZipPath)+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create zip file: %w", err)
 	}
-	defer zipfile.Close()
 
-	archive := zip.NewWriter(zipfile)
-	defer archive.Close()
+	err = writeZip(zipfile, sources, onProgress)
+	if closeErr := zipfile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write zip file: %w", closeErr)
+	}
+	if err == nil {
+		err = os.Chmod(zipfile.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(zipfile.Name(), targetZipPath)
+	}
+	if err != nil {
+		os.Remove(zipfile.Name())
+		return err
+	}
+
+	log.Printf("✅ Zip archive created successfully.")
+	return nil
+}
+
+func writeZip(w io.Writer, sources []ZipSource, onProgress ProgressFunc) error {
+	counter := &countingWriter{w: w}
+	archive := zip.NewWriter(counter)
 
 	for _, source := range sources {
 		if _, err := os.Stat(source.Path); os.IsNotExist(err) {
@@ -47,6 +86,9 @@ func ZipMultipleDirectories(sources []ZipSource, targetZipPath string) error {
 			if err != nil {
 				return err
 			}
+			if info.IsDir() && path != source.Path && excludedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
 
 			header, err := zip.FileInfoHeader(info)
 			if err != nil {
@@ -77,6 +119,12 @@ func ZipMultipleDirectories(sources []ZipSource, targetZipPath string) error {
 				if err != nil {
 					return err
 				}
+				if err := archive.Flush(); err != nil {
+					return err
+				}
+				if onProgress != nil {
+					onProgress(counter.written)
+				}
 			}
 			return nil
 		})
@@ -84,7 +132,10 @@ func ZipMultipleDirectories(sources []ZipSource, targetZipPath string) error {
 			return err
 		}
 	}
-	log.Printf("✅ Zip archive created successfully.")
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write zip file: %w", err)
+	}
 	return nil
 }
 
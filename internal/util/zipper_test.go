@@ -0,0 +1,83 @@
+package util
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestZipDirectorySkipsDownloaderState(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "123")
+	for name, content := range map[string]string{
+		"mod.txt":                         "mod",
+		"maps/map.bsp":                    "map",
+		".DepotDownloader/depot.manifest": "state",
+	} {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := filepath.Join(dir, "123.zip")
+	if err := ZipDirectory(source, target, nil); err != nil {
+		t.Fatalf("ZipDirectory: %v", err)
+	}
+
+	r, err := zip.OpenReader(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, filepath.ToSlash(f.Name))
+	}
+	slices.Sort(names)
+
+	want := []string{"123/", "123/maps/", "123/maps/map.bsp", "123/mod.txt"}
+	if !slices.Equal(names, want) {
+		t.Errorf("archive holds %q, want %q", names, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(source, ".DepotDownloader", "depot.manifest")); err != nil {
+		t.Errorf("downloader state was removed: %v", err)
+	}
+}
+
+func TestZipDirectoryFailureLeavesNoArchive(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "123")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A dangling link cannot be opened, which fails the archive midway.
+	if err := os.WriteFile(filepath.Join(source, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(source, "b.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	target := filepath.Join(dir, "123.zip")
+	if err := ZipDirectory(source, target, nil); err == nil {
+		t.Fatal("ZipDirectory succeeded with an unreadable file")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "123" {
+			t.Errorf("failed archive left %s behind", entry.Name())
+		}
+	}
+}
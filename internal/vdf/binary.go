@@ -0,0 +1,126 @@
+package vdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Binary KeyValues type tags.
+const (
+	binaryObject  = 0x00
+	binaryString  = 0x01
+	binaryInt32   = 0x02
+	binaryFloat32 = 0x03
+	binaryPointer = 0x04
+	binaryWString = 0x05
+	binaryColor   = 0x06
+	binaryUint64  = 0x07
+	binaryEnd     = 0x08
+	binaryInt64   = 0x0A
+	binaryEndAlt  = 0x0B
+)
+
+// ParseBinary reads binary KeyValues. Numbers are converted to their decimal
+// text so the result can be queried like a parsed text file.
+func ParseBinary(data []byte) (*Node, error) {
+	p := &binaryParser{r: bytes.NewReader(data)}
+
+	root := &Node{IsObject: true}
+	if err := p.parseObject(root, false); err != nil {
+		return nil, fmt.Errorf("vdf: offset %d: %w", len(data)-p.r.Len(), err)
+	}
+	return root, nil
+}
+
+type binaryParser struct {
+	r *bytes.Reader
+}
+
+func (p *binaryParser) parseObject(obj *Node, nested bool) error {
+	for {
+		tag, err := p.r.ReadByte()
+		if err != nil {
+			if !nested {
+				return nil
+			}
+			return errors.New("unexpected end of input")
+		}
+		if tag == binaryEnd || tag == binaryEndAlt {
+			return nil
+		}
+
+		key, err := p.cstring()
+		if err != nil {
+			return err
+		}
+		node := &Node{Key: key}
+
+		switch tag {
+		case binaryObject:
+			node.IsObject = true
+			if err := p.parseObject(node, true); err != nil {
+				return err
+			}
+		case binaryString:
+			node.Value, err = p.cstring()
+		case binaryWString:
+			node.Value, err = p.wstring()
+		case binaryInt32, binaryPointer, binaryColor:
+			var v int32
+			err = binary.Read(p.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatInt(int64(v), 10)
+		case binaryFloat32:
+			var v uint32
+			err = binary.Read(p.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatFloat(float64(math.Float32frombits(v)), 'f', -1, 32)
+		case binaryUint64:
+			var v uint64
+			err = binary.Read(p.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatUint(v, 10)
+		case binaryInt64:
+			var v int64
+			err = binary.Read(p.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatInt(v, 10)
+		default:
+			return fmt.Errorf("unknown type 0x%02x for key %q", tag, key)
+		}
+		if err != nil {
+			return fmt.Errorf("reading %q: %w", key, err)
+		}
+
+		obj.Children = append(obj.Children, node)
+	}
+}
+
+func (p *binaryParser) cstring() (string, error) {
+	var b []byte
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return "", errors.New("unterminated string")
+		}
+		if c == 0 {
+			return string(b), nil
+		}
+		b = append(b, c)
+	}
+}
+
+func (p *binaryParser) wstring() (string, error) {
+	var units []uint16
+	for {
+		var u uint16
+		if err := binary.Read(p.r, binary.LittleEndian, &u); err != nil {
+			return "", errors.New("unterminated wide string")
+		}
+		if u == 0 {
+			return string(utf16.Decode(units)), nil
+		}
+		units = append(units, u)
+	}
+}
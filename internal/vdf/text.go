@@ -0,0 +1,178 @@
+package vdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Parse reads text KeyValues and returns an object node holding the
+// top-level entries, e.g. the single "AppState" object of an appmanifest.
+func Parse(data []byte) (*Node, error) {
+	p := &textParser{data: data, line: 1}
+
+	root := &Node{IsObject: true}
+	if err := p.parseObject(root, false); err != nil {
+		return nil, fmt.Errorf("vdf: line %d: %w", p.line, err)
+	}
+	return root, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenString
+	tokenOpen
+	tokenClose
+)
+
+type textParser struct {
+	data []byte
+	pos  int
+	line int
+}
+
+func (p *textParser) parseObject(obj *Node, nested bool) error {
+	for {
+		kind, key, err := p.next()
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case tokenEOF:
+			if nested {
+				return errors.New("unexpected end of input, missing '}'")
+			}
+			return nil
+		case tokenClose:
+			if !nested {
+				return errors.New("unexpected '}'")
+			}
+			return nil
+		case tokenOpen:
+			return errors.New("unexpected '{', expected a key")
+		}
+
+		kind, value, err := p.next()
+		if err != nil {
+			return err
+		}
+
+		node := &Node{Key: key}
+		switch kind {
+		case tokenString:
+			node.Value = value
+		case tokenOpen:
+			node.IsObject = true
+			if err := p.parseObject(node, true); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("missing value for key %q", key)
+		}
+
+		p.skipConditional()
+		obj.Children = append(obj.Children, node)
+	}
+}
+
+// next returns the next token, skipping whitespace and // comments.
+func (p *textParser) next() (tokenKind, string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return tokenEOF, "", nil
+	}
+
+	switch c := p.data[p.pos]; c {
+	case '{':
+		p.pos++
+		return tokenOpen, "", nil
+	case '}':
+		p.pos++
+		return tokenClose, "", nil
+	case '"':
+		s, err := p.quoted()
+		return tokenString, s, err
+	default:
+		start := p.pos
+		for p.pos < len(p.data) && !isDelimiter(p.data[p.pos]) {
+			p.pos++
+		}
+		return tokenString, string(p.data[start:p.pos]), nil
+	}
+}
+
+func (p *textParser) quoted() (string, error) {
+	p.pos++ // opening quote
+
+	var b strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\n':
+			p.line++
+		case '\\':
+			if p.pos < len(p.data) {
+				switch e := p.data[p.pos]; e {
+				case 'n':
+					c = '\n'
+				case 't':
+					c = '\t'
+				case '\\', '"':
+					c = e
+				default:
+					b.WriteByte('\\')
+					c = e
+				}
+				p.pos++
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	return "", errors.New("unterminated string")
+}
+
+// skipConditional drops platform conditionals such as [$WIN32] that may
+// follow a value.
+func (p *textParser) skipConditional() {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '[' {
+		for p.pos < len(p.data) && p.data[p.pos] != ']' {
+			p.pos++
+		}
+		p.pos++
+	}
+}
+
+func (p *textParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '{', '}', '"':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Package vdf reads Valve's KeyValues format, used by steamcmd for its .acf
+// manifests (text) and for files like appinfo.vdf (binary).
+package vdf
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Node is either a value or an object holding further nodes. Keys are
+// matched case-insensitively, as Steam does.
+type Node struct {
+	Key      string
+	Value    string
+	Children []*Node
+	// IsObject tells an empty object apart from an empty value.
+	IsObject bool
+}
+
+// Get walks path from n and returns nil if any key along it is missing.
+func (n *Node) Get(path ...string) *Node {
+	for _, key := range path {
+		if n == nil {
+			return nil
+		}
+		n = n.child(key)
+	}
+	return n
+}
+
+func (n *Node) child(key string) *Node {
+	for _, c := range n.Children {
+		if strings.EqualFold(c.Key, key) {
+			return c
+		}
+	}
+	return nil
+}
+
+// String returns the value at path, or "" if there is none.
+func (n *Node) String(path ...string) string {
+	if v := n.Get(path...); v != nil && !v.IsObject {
+		return v.Value
+	}
+	return ""
+}
+
+// Int returns the value at path as an int64, or 0 if it is missing or not a
+// number.
+func (n *Node) Int(path ...string) int64 {
+	i, _ := strconv.ParseInt(n.String(path...), 10, 64)
+	return i
+}
+
+// Uint is like Int for values such as manifest IDs that need all 64 bits.
+func (n *Node) Uint(path ...string) uint64 {
+	u, _ := strconv.ParseUint(n.String(path...), 10, 64)
+	return u
+}
+
+func ParseFile(path string) (*Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, nil
+}
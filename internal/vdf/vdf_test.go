@@ -0,0 +1,196 @@
+package vdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+const workshopManifest = `"AppWorkshop"
+{
+	"appid"		"4000"
+	"SizeOnDisk"		"1234"
+	// comment
+	"WorkshopItemsInstalled"
+	{
+		"123"
+		{
+			"size"		"1234"
+			"timeupdated"		"1700000000"
+			"manifest"		"9223372036854775809"
+		}
+	}
+	"WorkshopItemDetails"
+	{
+	}
+}
+`
+
+func TestParse(t *testing.T) {
+	root, err := Parse([]byte(workshopManifest))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"AppWorkshop", "appid"}, "4000"},
+		{[]string{"appworkshop", "SIZEONDISK"}, "1234"},
+		{[]string{"AppWorkshop", "WorkshopItemsInstalled", "123", "timeupdated"}, "1700000000"},
+		{[]string{"AppWorkshop", "WorkshopItemsInstalled", "123"}, ""},
+		{[]string{"AppWorkshop", "missing"}, ""},
+		{[]string{"AppWorkshop", "appid", "below a value"}, ""},
+	}
+	for _, tt := range tests {
+		if got := root.String(tt.path...); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got := root.Int("AppWorkshop", "WorkshopItemsInstalled", "123", "size"); got != 1234 {
+		t.Errorf("Int(size) = %d, want 1234", got)
+	}
+	if got := root.Uint("AppWorkshop", "WorkshopItemsInstalled", "123", "manifest"); got != 9223372036854775809 {
+		t.Errorf("Uint(manifest) = %d, want 9223372036854775809", got)
+	}
+	if details := root.Get("AppWorkshop", "WorkshopItemDetails"); details == nil || !details.IsObject || len(details.Children) != 0 {
+		t.Errorf("WorkshopItemDetails = %+v, want an empty object", details)
+	}
+}
+
+func TestParseSyntax(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		want  string
+	}{
+		{"unquoted", `key value`, "key", "value"},
+		{"escapes", `"key" "a\"b\\c\nd"`, "key", "a\"b\\c\nd"},
+		{"unknown escape kept", `"key" "C:\path"`, "key", `C:\path`},
+		{"conditional", `"key" "value" [$WIN32] "next" "2"`, "next", "2"},
+		{"empty value", `"key" ""`, "key", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := root.String(tt.key); got != tt.want {
+				t.Errorf("String(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing brace", "\"a\"\n{\n\"b\" \"c\"\n", "line 4: unexpected end of input"},
+		{"stray brace", `"a" "b" }`, "unexpected '}'"},
+		{"open instead of key", `{`, "expected a key"},
+		{"missing value", `"a" {} "b"`, `missing value for key "b"`},
+		{"unterminated string", `"a" "b`, "unterminated string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// binaryBuilder writes binary KeyValues for the tests.
+type binaryBuilder struct {
+	bytes.Buffer
+}
+
+func (b *binaryBuilder) key(tag byte, key string) {
+	b.WriteByte(tag)
+	b.WriteString(key)
+	b.WriteByte(0)
+}
+
+func (b *binaryBuilder) value(v any) {
+	binary.Write(&b.Buffer, binary.LittleEndian, v)
+}
+
+func TestParseBinary(t *testing.T) {
+	var b binaryBuilder
+	b.key(binaryObject, "appinfo")
+	b.key(binaryString, "name")
+	b.WriteString("Garry's Mod\x00")
+	b.key(binaryInt32, "appid")
+	b.value(int32(4000))
+	b.key(binaryInt32, "negative")
+	b.value(int32(-5))
+	b.key(binaryFloat32, "ratio")
+	b.value(math.Float32bits(1.5))
+	b.key(binaryUint64, "manifest")
+	b.value(uint64(math.MaxUint64))
+	b.key(binaryInt64, "signed")
+	b.value(int64(math.MinInt64))
+	b.key(binaryWString, "wide")
+	b.value([]uint16{'h', 0xe9, 0})
+	b.key(binaryObject, "empty")
+	b.WriteByte(binaryEnd)
+	b.WriteByte(binaryEnd)
+	b.WriteByte(binaryEnd)
+
+	root, err := ParseBinary(b.Bytes())
+	if err != nil {
+		t.Fatalf("ParseBinary: %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"name", "Garry's Mod"},
+		{"appid", "4000"},
+		{"negative", "-5"},
+		{"ratio", "1.5"},
+		{"manifest", "18446744073709551615"},
+		{"signed", "-9223372036854775808"},
+		{"wide", "hé"},
+	}
+	for _, tt := range tests {
+		if got := root.String("appinfo", tt.key); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	if empty := root.Get("appinfo", "empty"); empty == nil || !empty.IsObject {
+		t.Errorf("empty = %+v, want an object", empty)
+	}
+}
+
+func TestParseBinaryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"unterminated object", []byte("\x00a\x00"), "unexpected end of input"},
+		{"unterminated key", []byte("\x01abc"), "unterminated string"},
+		{"short int", []byte("\x02n\x00\x01\x02"), `reading "n"`},
+		{"unknown type", []byte("\x09n\x00"), "unknown type 0x09"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBinary(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseBinary error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}